@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/arun0009/hatchetest/pkg/apitoken"
+	"github.com/arun0009/hatchetest/pkg/config"
+)
+
+const usage = `Usage:
+  hatchetest [flags]          run the server and Hatchet worker; see hatchetest -h for flags
+  hatchetest token create     create a Hatchet API token and print it
+  hatchetest config print     print the effective configuration, secrets redacted; takes the server flags`
+
+// runCommand dispatches CLI subcommands
+func runCommand(args []string) error {
+	switch {
+	case len(args) >= 2 && args[0] == "token" && args[1] == "create":
+		return runTokenCreate(args[2:])
+	case len(args) >= 2 && args[0] == "config" && args[1] == "print":
+		return runConfigPrint(args[2:])
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args, usage)
+	}
+}
+
+// runTokenCreate creates an API token, by default through the Hatchet REST API.
+// With --admin-bin it runs hatchet-admin instead, which needs the server config directory.
+func runTokenCreate(args []string) error {
+	fs := flag.NewFlagSet("token create", flag.ContinueOnError)
+	name := fs.String("name", "default", "name of the token")
+	tenantID := fs.String("tenant-id", apitoken.DefaultTenantID, "tenant the token is scoped to")
+	expiresIn := fs.Duration("expires-in", apitoken.DefaultExpiresIn, "token lifetime")
+	serverURL := fs.String("server-url", os.Getenv("HATCHET_CLIENT_SERVER_URL"), "Hatchet REST API URL")
+	email := fs.String("admin-email", envOr("HATCHET_ADMIN_EMAIL", apitoken.DefaultAdminEmail), "admin user email for the REST API")
+	password := fs.String("admin-password", envOr("HATCHET_ADMIN_PASSWORD", apitoken.DefaultAdminPassword), "admin user password for the REST API")
+	adminBin := fs.String("admin-bin", "", "path to hatchet-admin; when set, the token is created with it instead of the REST API")
+	configDir := fs.String("config", "/config", "Hatchet server config directory for hatchet-admin")
+	asJSON := fs.Bool("json", false, "print the token and its claims as JSON")
+	err := fs.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	var provisioner apitoken.TokenProvisioner
+	if *adminBin != "" {
+		provisioner = &apitoken.AdminCommandProvisioner{Binary: *adminBin, ConfigDir: *configDir}
+	} else {
+		if *serverURL == "" {
+			return fmt.Errorf("--server-url or HATCHET_CLIENT_SERVER_URL is required")
+		}
+		session, err := apitoken.Login(ctx, *serverURL, *email, *password)
+		if err != nil {
+			return err
+		}
+		provisioner = &apitoken.RESTProvisioner{Session: session}
+	}
+
+	token, err := provisioner.Create(ctx, apitoken.CreateRequest{
+		Name:      *name,
+		TenantID:  *tenantID,
+		ExpiresIn: *expiresIn,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(token)
+	}
+	fmt.Println(token.Raw)
+	return nil
+}
+
+// runConfigPrint prints what the server would run with given the same environment and flags,
+// in the format served by GET /admin/config
+func runConfigPrint(args []string) error {
+	cfg, err := config.Load(config.WithEnviron(os.Environ()), config.WithArgs(args))
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cfg.Effective())
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
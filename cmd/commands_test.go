@@ -0,0 +1,12 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenCreateHelp(t *testing.T) {
+	assert.NoError(t, runTokenCreate([]string{"-h"}))
+	assert.Error(t, runTokenCreate([]string{"--no-such-flag"}))
+}
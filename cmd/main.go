@@ -1,95 +1,270 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
-	"net"
+	"log/slog"
+	"net/http"
 	"os"
-	"strconv"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/arun0009/hatchetest/pkg/admin"
+	"github.com/arun0009/hatchetest/pkg/api"
 	"github.com/arun0009/hatchetest/pkg/config"
-	"github.com/hatchet-dev/hatchet/pkg/client"
+	"github.com/arun0009/hatchetest/pkg/health"
+	"github.com/arun0009/hatchetest/pkg/logging"
+	"github.com/arun0009/hatchetest/pkg/supervisor"
 	"github.com/hatchet-dev/hatchet/pkg/worker"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
 
+const (
+	// tokenPollInterval is how often a mounted token file is checked for rotation
+	tokenPollInterval = 30 * time.Second
+
+	workerName = "hatchetest-worker"
+)
+
+// Exit codes of the server
+const (
+	exitOK = 0
+	// exitFailure is a configuration, startup, server or worker failure
+	exitFailure = 1
+	// exitShutdownTimeout means HTTP requests or step runs were still running when ShutdownTimeout ran out
+	exitShutdownTimeout = 3
+	// exitTokenRotated means the Hatchet token file changed; the server shut down so it can be restarted
+	// with the new token
+	exitTokenRotated = 4
+)
+
 func main() {
-	// Load application configuration
-	cfg, err := config.LoadAppConfig()
-	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+	// Subcommands run instead of the server; anything else is server flags
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		if err := runCommand(args); err != nil {
+			log.Fatalf("%v", err)
+		}
+		return
 	}
 
-	// Initialize Hatchet client (required)
-	if cfg.HatchetToken == "" {
-		log.Fatalf("HATCHET_CLIENT_TOKEN is required")
-	}
-	if cfg.HatchetServerURL == "" {
-		log.Fatalf("HATCHET_CLIENT_SERVER_URL is required")
-	}
+	os.Exit(serve(args))
+}
 
-	host, port, err := net.SplitHostPort(cfg.HatchetHostPort)
-	if err != nil {
-		log.Fatalf("Error splitting hatchet host and port: %v", err)
+// serve runs the HTTP server and the Hatchet worker until SIGINT or SIGTERM, or until either fails,
+// then shuts both down and returns the exit code
+func serve(args []string) int {
+	// Load application configuration; the watcher re-runs this on SIGHUP or when the config file changes
+	watcher, err := config.NewWatcher(func() (*config.AppConfig, error) {
+		return config.Load(config.WithEnviron(os.Environ()), config.WithArgs(args))
+	})
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
 	}
-	portInt, err := strconv.Atoi(port)
 	if err != nil {
-		log.Fatalf("Error converting hatchet port to int: %v", err)
+		log.Printf("Failed to load configuration: %v", err)
+		return exitFailure
 	}
+	cfg := watcher.Config()
 
-	// Set environment variables for Hatchet client initialization
-	// This is needed because defaultClientOpts loads from env vars first
-	os.Setenv("HATCHET_CLIENT_TOKEN", cfg.HatchetToken)
-	os.Setenv("HATCHET_CLIENT_HOST_PORT", cfg.HatchetHostPort)
-	os.Setenv("HATCHET_CLIENT_SERVER_URL", cfg.HatchetServerURL)
+	// Structured logger for the server, the worker and request logs; LogLevel can change on reload
+	level := new(slog.LevelVar)
+	setLevel(level, cfg.LogLevel)
+	logger := logging.New(os.Stderr, cfg.LogFormat, level)
+	slog.SetDefault(logger)
+	logger.Info("configuration loaded",
+		"profile", cfg.AppEnv, "profile_source", cfg.Source("AppEnv"), "overrides", describeOverrides(cfg))
 
-	hatchetClient, err := client.New(
-		client.WithToken(cfg.HatchetToken),
-		client.WithHostPort(host, portInt),
-	)
+	// Cancelled on SIGINT or SIGTERM, which starts the shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Initialize Hatchet client (required)
+	hatchetClient, err := cfg.NewHatchetClient()
 	if err != nil {
-		log.Fatalf("Failed to initialize Hatchet client: %v", err)
+		logger.Error("failed to initialize Hatchet client", "error", err)
+		return exitFailure
 	}
 
+	// The SDK keeps the token it was created with, so a rotated token file shuts the server down to be
+	// restarted with it
+	tokenRotated := watchToken(ctx, cfg, cfg.TokenProvider(), tokenPollInterval, logger)
+
 	// Create Echo server
 	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
 
 	// Add middleware
-	e.Use(middleware.Logger())
+	e.Use(logging.Echo(logger))
 	e.Use(middleware.Recover())
-	e.Use(middleware.CORS())
+	if len(cfg.CORSAllowOrigins) > 0 {
+		e.Use(middleware.CORSWithConfig(middleware.CORSConfig{AllowOrigins: cfg.CORSAllowOrigins}))
+	}
 
-	// Health check endpoint
-	e.GET("/health", func(c echo.Context) error {
-		return c.JSON(200, map[string]string{
-			"status":  "healthy",
-			"service": "hatchetest",
-		})
+	watcher.Subscribe(func(event config.ChangeEvent) {
+		for _, change := range event.Changes {
+			logger.Info("configuration changed", "change", change.String())
+		}
+		setLevel(level, event.Config.LogLevel)
+	})
+	watcher.OnError(func(err error) {
+		logger.Error("keeping current configuration", "error", err)
 	})
+	go watcher.Run(ctx)
 
 	// Register Hatchet workflows with worker
-	w, err := worker.NewWorker(
-		worker.WithClient(hatchetClient),
-		worker.WithName("hatchetest-worker"),
+	sup, err := supervisor.New(hatchetClient, workerName, logger,
+		supervisor.WithWorkerOpts(worker.WithLogLevel(cfg.LogLevel)),
+		supervisor.WithStartTimeout(cfg.WorkerStartTimeout),
+		supervisor.WithRetry(cfg.WorkerMaxRetries, cfg.WorkerRetryBackoff, cfg.WorkerRetryMaxBackoff),
 	)
 	if err != nil {
-		log.Fatalf("Failed to create Hatchet worker: %v", err)
+		logger.Error("failed to create Hatchet worker", "error", err)
+		return exitFailure
 	}
+	sup.Worker().Use(logging.Worker(logger))
 
-	// Start Hatchet worker
-	go func() {
-		log.Println("Starting Hatchet worker...")
-		cleanup, err := w.Start()
-		if err != nil {
-			log.Printf("Hatchet worker error: %v", err)
+	// Health endpoints: /readyz checks Hatchet, the worker and Postgres; /livez only that the server serves
+	checks := health.NewRegistry(health.DefaultTimeout)
+	checks.Register("hatchet", health.Hatchet(hatchetClient), health.Readiness)
+	checks.Register("worker", sup, health.Readiness)
+	if cfg.DatabaseURL != "" {
+		checks.Register("postgres", health.Postgres(cfg.DatabaseURL), health.Readiness)
+	}
+	health.Register(e, checks)
+
+	// Admin endpoints, authenticated with ADMIN_TOKEN
+	admin.Register(e, watcher.Config)
+
+	// HTTP API for triggering and following workflow runs; input schemas are read once at startup
+	schemas := api.NewSchemas()
+	if cfg.WorkflowSchemaDir != "" {
+		if err := schemas.LoadDir(cfg.WorkflowSchemaDir); err != nil {
+			logger.Error("failed to load workflow schemas", "dir", cfg.WorkflowSchemaDir, "error", err)
+			return exitFailure
 		}
-		defer cleanup()
+	}
+	api.New(hatchetClient, watcher.Config, schemas, logger).Register(e)
+
+	// Start Hatchet worker; cancelling workerCtx stops it taking new steps
+	workerCtx, stopWorker := context.WithCancel(context.Background())
+	defer stopWorker()
+	var workerErr error
+	workerDone := make(chan struct{})
+	go func() {
+		defer close(workerDone)
+		workerErr = sup.Run(workerCtx)
 	}()
 
 	// Start server
-	log.Printf("Starting unified server on port %d", cfg.Port)
-	if err := e.Start(fmt.Sprintf(":%d", cfg.Port)); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("starting unified server", "port", cfg.Port)
+		if err := e.Start(fmt.Sprintf(":%d", cfg.Port)); !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	code := exitOK
+	select {
+	case <-ctx.Done():
+		logger.Info("shutting down")
+	case <-tokenRotated:
+		logger.Warn("Hatchet token was rotated; shutting down to restart with it", "file", cfg.HatchetTokenFile)
+		code = exitTokenRotated
+	case err := <-serverErr:
+		logger.Error("server failed", "error", err)
+		code = exitFailure
+	case <-workerDone:
+		err := workerErr
+		if err == nil {
+			err = errors.New("hatchet worker exited")
+		}
+		logger.Error("worker failed", "error", err)
+		code = exitFailure
+	}
+
+	// A second signal during shutdown kills the process as usual
+	stop()
+	if err := shutdown(e, sup, stopWorker, workerDone, watcher.Config().ShutdownTimeout); err != nil {
+		logger.Error("shutdown did not finish in time", "error", err)
+		if code == exitOK {
+			code = exitShutdownTimeout
+		}
+	}
+	logger.Info("stopped", "exit_code", code)
+	return code
+}
+
+// watchToken re-reads the Hatchet token from p every interval until ctx is done, and closes the returned
+// channel the first time it differs from the token cfg was loaded with. Without a token file the channel
+// is never closed.
+func watchToken(ctx context.Context, cfg *config.AppConfig, p config.SecretProvider, interval time.Duration, logger *slog.Logger) <-chan struct{} {
+	rotated := make(chan struct{})
+	if cfg.HatchetTokenFile == "" {
+		return rotated
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var once sync.Once
+	go config.WatchSecret(ctx, p, cfg.HatchetToken, interval,
+		func(string) {
+			once.Do(func() { close(rotated) })
+			cancel()
+		},
+		func(err error) {
+			logger.Error("failed to re-read Hatchet token", "file", cfg.HatchetTokenFile, "error", err)
+		})
+	return rotated
+}
+
+// shutdown stops the HTTP server taking requests and waits for those in flight, then stops the worker
+// taking steps and waits for the running ones, all within timeout
+func shutdown(e *echo.Echo, sup *supervisor.Supervisor, stopWorker context.CancelFunc, workerDone <-chan struct{}, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var errs []error
+	if err := e.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("HTTP server: %w", err))
+	}
+
+	stopWorker()
+	select {
+	case <-workerDone:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("hatchet worker did not stop: %w", ctx.Err()))
+	}
+	if err := sup.Drain(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// describeOverrides lists the fields that did not come from plain defaults, with their source
+func describeOverrides(cfg *config.AppConfig) []string {
+	var parts []string
+	for field, src := range cfg.Sources() {
+		if src != config.SourceDefault && field != "AppEnv" {
+			parts = append(parts, fmt.Sprintf("%s=%s", field, src))
+		}
+	}
+	sort.Strings(parts)
+	return parts
+}
+
+// setLevel applies a validated LogLevel
+func setLevel(level *slog.LevelVar, logLevel string) {
+	if l, err := logging.ParseLevel(logLevel); err == nil {
+		level.Set(l)
 	}
 }
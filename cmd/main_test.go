@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/arun0009/hatchetest/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// countedSecret signals reads after each read of the wrapped provider, successful or not
+type countedSecret struct {
+	config.SecretProvider
+	reads chan struct{}
+}
+
+func (c *countedSecret) Secret(ctx context.Context) (string, error) {
+	defer func() {
+		select {
+		case c.reads <- struct{}{}:
+		case <-ctx.Done():
+		}
+	}()
+	return c.SecretProvider.Secret(ctx)
+}
+
+// watchTokenFile watches file as the token file of a config loaded with token, and returns the rotation
+// channel and the reads of the file
+func watchTokenFile(t *testing.T, file, token string) (<-chan struct{}, <-chan struct{}) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	cfg := &config.AppConfig{HatchetToken: token, HatchetTokenFile: file}
+	p := &countedSecret{SecretProvider: config.FileSecret{Path: file}, reads: make(chan struct{})}
+	return watchToken(ctx, cfg, p, 10*time.Millisecond, discard), p.reads
+}
+
+// awaitReads waits for n reads of the token file, failing if it is rotated first
+func awaitReads(t *testing.T, rotated, reads <-chan struct{}, n int) {
+	t.Helper()
+	for range n {
+		select {
+		case <-reads:
+		case <-rotated:
+			t.Fatal("rotation signalled while the token was unchanged")
+		case <-time.After(2 * time.Second):
+			t.Fatal("token file was not re-read")
+		}
+	}
+}
+
+func awaitRotation(t *testing.T, rotated, reads <-chan struct{}) {
+	t.Helper()
+	for {
+		select {
+		case <-rotated:
+			return
+		case <-reads:
+		case <-time.After(2 * time.Second):
+			t.Fatal("rotation was not signalled")
+		}
+	}
+}
+
+func TestWatchTokenSignalsRotation(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "hatchet-token")
+	require.NoError(t, os.WriteFile(file, []byte("old-token\n"), 0o600))
+	rotated, reads := watchTokenFile(t, file, "old-token")
+
+	awaitReads(t, rotated, reads, 2)
+	require.NoError(t, os.WriteFile(file, []byte("new-token\n"), 0o600))
+	awaitRotation(t, rotated, reads)
+}
+
+func TestWatchTokenComparesWithLoadedToken(t *testing.T) {
+	// The file was rotated between loading the config and starting the watch
+	file := filepath.Join(t.TempDir(), "hatchet-token")
+	require.NoError(t, os.WriteFile(file, []byte("new-token\n"), 0o600))
+	rotated, reads := watchTokenFile(t, file, "old-token")
+	awaitRotation(t, rotated, reads)
+}
+
+func TestWatchTokenIgnoresFailedReads(t *testing.T) {
+	// The file is missing for a moment while it is swapped
+	file := filepath.Join(t.TempDir(), "hatchet-token")
+	rotated, reads := watchTokenFile(t, file, "old-token")
+
+	awaitReads(t, rotated, reads, 2)
+	require.NoError(t, os.WriteFile(file, []byte("old-token\n"), 0o600))
+	awaitReads(t, rotated, reads, 3)
+}
+
+func TestWatchTokenWithoutFile(t *testing.T) {
+	rotated := watchToken(context.Background(), &config.AppConfig{HatchetToken: "token"}, config.StaticSecret("other"), 10*time.Millisecond, discard)
+	select {
+	case <-rotated:
+		t.Fatal("rotation signalled without a token file")
+	default:
+	}
+}
@@ -1,17 +1,21 @@
-#!/usr/bin/env bash
+#!/usr/bin/env sh
 set -e
 
 # Wait for Hatchet to be ready
 echo "Waiting for Hatchet to start..."
-until curl -sSf http://hatchet-lite:8888/health >/dev/null 2>&1; do
+until wget -q --spider "${HATCHET_CLIENT_SERVER_URL}/health" >/dev/null 2>&1; do
     sleep 2
 done
 
-# Request a token dynamically
-echo "Generating Hatchet client token..."
-HATCHET_CLIENT_TOKEN=$(hatchet token create --tenant-id default --config /config | tr -d '\r\n')
+# Request a token for the seeded default tenant through the Hatchet REST API, unless one
+# is mounted already. It is kept in a file rather than the environment of the app.
+if [ -z "${HATCHET_CLIENT_TOKEN_FILE}" ]; then
+    echo "Generating Hatchet client token..."
+    HATCHET_CLIENT_TOKEN_FILE="${HOME:-/tmp}/.hatchet-token"
+    (umask 077 && ./hatchetest token create --name hatchetest > "${HATCHET_CLIENT_TOKEN_FILE}")
+fi
 
-export HATCHET_CLIENT_TOKEN
+export HATCHET_CLIENT_TOKEN_FILE
 
 # Run the main application
 exec "$@"
@@ -31,6 +31,8 @@ func (s *IntegrationTestSuite) TestGlobalSharedContainers() {
 	// Verify containers are running
 	s.Require().NotEmpty(s.TestServerURL, "Test server URL should be available")
 	s.Require().NotEmpty(s.HatchetURL, "Hatchet URL should be available")
+	s.Require().NotEmpty(s.HatchetGRPCURL, "Hatchet GRPC URL should be available")
+	s.Require().NotNil(s.HatchetClient, "Hatchet client should be created")
 
 	s.T().Log("✅ Global shared containers are running successfully")
 	s.T().Log("✅ Postgres container is running")
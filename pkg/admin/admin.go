@@ -0,0 +1,27 @@
+// Package admin serves operational endpoints for the hatchetest server
+package admin
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/arun0009/hatchetest/pkg/config"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// Register adds the /admin routes. current returns the configuration in effect, e.g. Watcher.Config.
+// Requests must carry "Authorization: Bearer <AdminToken>"; while AdminToken is empty every request is refused.
+func Register(e *echo.Echo, current func() *config.AppConfig) {
+	g := e.Group("/admin", middleware.KeyAuthWithConfig(middleware.KeyAuthConfig{
+		Validator: func(key string, _ echo.Context) (bool, error) {
+			token := current().AdminToken
+			return token != "" && subtle.ConstantTimeCompare([]byte(key), []byte(token)) == 1, nil
+		},
+	}))
+
+	// Effective configuration, with secrets redacted and the source of each value
+	g.GET("/config", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, current().Effective())
+	})
+}
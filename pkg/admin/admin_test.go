@@ -0,0 +1,51 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/arun0009/hatchetest/pkg/config"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getConfig(e *echo.Echo, auth string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/admin/config", nil)
+	if auth != "" {
+		req.Header.Set(echo.HeaderAuthorization, auth)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestConfigRequiresAdminToken(t *testing.T) {
+	cfg, err := config.Load(config.WithEnviron([]string{"ADMIN_TOKEN=admin-secret", "HATCHET_CLIENT_TOKEN=hatchet-secret"}))
+	require.NoError(t, err)
+	e := echo.New()
+	Register(e, func() *config.AppConfig { return cfg })
+
+	assert.Equal(t, http.StatusBadRequest, getConfig(e, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, getConfig(e, "Bearer wrong").Code)
+
+	rec := getConfig(e, "Bearer admin-secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "secret")
+
+	var got config.Effective
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, config.ProfileDev, got.Profile)
+	assert.Len(t, got.Settings, len(cfg.Effective().Settings))
+}
+
+func TestConfigDisabledWithoutAdminToken(t *testing.T) {
+	cfg, err := config.Load(config.WithEnviron(nil))
+	require.NoError(t, err)
+	e := echo.New()
+	Register(e, func() *config.AppConfig { return cfg })
+
+	assert.Equal(t, http.StatusUnauthorized, getConfig(e, "Bearer anything").Code)
+}
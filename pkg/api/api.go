@@ -0,0 +1,119 @@
+// Package api serves the /api/v1 HTTP API, which lets services trigger and follow Hatchet workflows
+// without embedding the SDK
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/arun0009/hatchetest/pkg/config"
+	"github.com/hatchet-dev/hatchet/pkg/client"
+	"github.com/labstack/echo/v4"
+)
+
+// Error codes of the API's error responses
+const (
+	CodeInvalidRequest   = "invalid_request"
+	CodeValidationFailed = "validation_failed"
+	CodeWorkflowNotFound = "workflow_not_found"
+	CodeRunNotFound      = "run_not_found"
+	CodeDuplicateRun     = "duplicate_run"
+	CodeKeyNotAllowed    = "event_key_not_allowed"
+	CodePayloadTooLarge  = "payload_too_large"
+	CodeRateLimited      = "rate_limited"
+	CodeHatchetError     = "hatchet_error"
+)
+
+// Error is the body of every API error response, under an "error" key
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Fields lists the request fields at fault, for validation errors
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError is one problem with a request field. Field is a dotted path such as "input.amount".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// API holds what the /api/v1 handlers share
+type API struct {
+	client  client.Client
+	current func() *config.AppConfig
+	schemas *Schemas
+	logger  *slog.Logger
+	// heartbeat is how often idle event streams get a comment line
+	heartbeat time.Duration
+
+	// stopping is cancelled when the HTTP server shuts down, to end long-polls early
+	stopping context.Context
+	stop     context.CancelFunc
+}
+
+// New returns an API that talks to Hatchet through c and checks workflow inputs against schemas,
+// which may be nil to accept any input. current returns the configuration in effect, e.g. Watcher.Config;
+// the event key allowlist and limits are read from it on each request.
+func New(c client.Client, current func() *config.AppConfig, schemas *Schemas, logger *slog.Logger) *API {
+	if schemas == nil {
+		schemas = NewSchemas()
+	}
+	stopping, stop := context.WithCancel(context.Background())
+	return &API{client: c, current: current, schemas: schemas, logger: logger, heartbeat: HeartbeatInterval, stopping: stopping, stop: stop}
+}
+
+// Register adds the /api/v1 routes to e. Long-polls end when e shuts down, so they do not hold up
+// the shutdown.
+func (a *API) Register(e *echo.Echo) {
+	e.Server.RegisterOnShutdown(a.stop)
+
+	g := e.Group("/api/v1")
+	g.POST("/workflows/:name/runs", a.triggerRun)
+	g.GET("/runs/:id", a.getRun)
+	g.GET("/runs/:id/result", a.getRunResult)
+	g.GET("/runs/:id/events", a.streamRun)
+	g.POST("/events", a.pushEvents)
+	g.POST("/events/:key", a.pushEvent)
+}
+
+// requestContext returns the request's context, also cancelled when the server shuts down
+func (a *API) requestContext(c echo.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(c.Request().Context())
+	stop := context.AfterFunc(a.stopping, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// fail writes an error response
+func fail(c echo.Context, status int, code, message string, fields ...FieldError) error {
+	return c.JSON(status, map[string]Error{"error": {Code: code, Message: message, Fields: fields}})
+}
+
+// invalid writes a 400 for a request that could not be read
+func invalid(c echo.Context, message string) error {
+	return fail(c, http.StatusBadRequest, CodeInvalidRequest, message)
+}
+
+// decodeLimited decodes the JSON request body into v, reading at most limit bytes
+func decodeLimited(c echo.Context, v any, limit int64) error {
+	dec := json.NewDecoder(http.MaxBytesReader(c.Response(), c.Request().Body, limit))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// bodyFailed maps an error from decodeLimited to a response: 413 over the limit, 400 otherwise
+func bodyFailed(c echo.Context, err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return fail(c, http.StatusRequestEntityTooLarge, CodePayloadTooLarge, fmt.Sprintf("request body is over the %d byte limit", tooLarge.Limit))
+	}
+	return invalid(c, fmt.Sprintf("invalid request body: %v", err))
+}
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/arun0009/hatchetest/pkg/config"
+	"github.com/google/uuid"
+	"github.com/hatchet-dev/hatchet/pkg/client/rest"
+	clientconfig "github.com/hatchet-dev/hatchet/pkg/config/client"
+	"github.com/labstack/echo/v4"
+)
+
+// eventOverheadBytes is allowed on top of the payload limit for each event's key, metadata and JSON framing
+const eventOverheadBytes = 16 << 10
+
+// EventRequest is the body of POST /api/v1/events/:key, and one event of a bulk push
+type EventRequest struct {
+	// Key is the event key of a bulk push; a single push takes it from the path
+	Key string `json:"key,omitempty"`
+	// Payload is the event data, a JSON object; it defaults to {}
+	Payload json.RawMessage `json:"payload,omitempty"`
+	// Metadata is attached to the event, and to the runs it triggers, as Hatchet additional metadata
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Priority is the priority of the runs the event triggers, from 1 (low) to 3 (high)
+	Priority *int32 `json:"priority,omitempty"`
+}
+
+// BulkEventRequest is the body of POST /api/v1/events
+type BulkEventRequest struct {
+	Events []EventRequest `json:"events"`
+}
+
+// EventResponse identifies a pushed event
+type EventResponse struct {
+	EventID string `json:"eventId"`
+	Key     string `json:"key"`
+}
+
+// BulkEventResponse is the body of a successful bulk push, in the order of the request
+type BulkEventResponse struct {
+	Events []EventResponse `json:"events"`
+}
+
+// eventProblems sorts what is wrong with pushed events by the status it gets
+type eventProblems struct {
+	tooLarge, notAllowed, invalid []FieldError
+}
+
+// pushEvent pushes one event under the key in the path and answers 202 with its ID.
+// The SDK's Event().Push does not return the event, so events go through the REST API, with the client's
+// namespace applied to the key as Push would.
+func (a *API) pushEvent(c echo.Context) error {
+	cfg := a.current()
+
+	var req EventRequest
+	if err := decodeLimited(c, &req, int64(cfg.EventMaxPayloadBytes)+eventOverheadBytes); err != nil {
+		return bodyFailed(c, err)
+	}
+	if req.Key != "" {
+		return invalid(c, "the event key is taken from the path; use POST /api/v1/events to push several keys")
+	}
+	req.Key = c.Param("key")
+
+	var problems eventProblems
+	event := problems.check(cfg, a.client.Namespace(), "", req)
+	if problems.any() {
+		return problems.respond(c)
+	}
+
+	tenantID, err := uuid.Parse(a.client.TenantId())
+	if err != nil {
+		return a.eventsFailed(c, fmt.Errorf("invalid tenant ID %q: %w", a.client.TenantId(), err))
+	}
+	resp, err := a.client.API().EventCreateWithResponse(c.Request().Context(), tenantID, event)
+	if err != nil {
+		return a.eventsFailed(c, err)
+	}
+	if resp.JSON200 == nil {
+		return a.hatchetRejected(c, resp.StatusCode(), resp.JSON400, resp.Status())
+	}
+
+	a.logger.InfoContext(c.Request().Context(), "event pushed", "key", req.Key, "event_id", resp.JSON200.Metadata.Id)
+	return c.JSON(http.StatusAccepted, EventResponse{EventID: resp.JSON200.Metadata.Id, Key: req.Key})
+}
+
+// pushEvents pushes several events, each with its own key, and answers 202 with their IDs. Nothing is
+// pushed unless every event passes the checks.
+func (a *API) pushEvents(c echo.Context) error {
+	cfg := a.current()
+
+	var req BulkEventRequest
+	limit := (int64(cfg.EventMaxPayloadBytes) + eventOverheadBytes) * int64(cfg.EventMaxBulkEvents)
+	if err := decodeLimited(c, &req, limit); err != nil {
+		return bodyFailed(c, err)
+	}
+	if len(req.Events) == 0 {
+		return fail(c, http.StatusBadRequest, CodeValidationFailed, "request failed validation", FieldError{Field: "events", Message: "must not be empty"})
+	}
+	if len(req.Events) > cfg.EventMaxBulkEvents {
+		return fail(c, http.StatusRequestEntityTooLarge, CodePayloadTooLarge, fmt.Sprintf("at most %d events can be pushed at once", cfg.EventMaxBulkEvents))
+	}
+
+	var problems eventProblems
+	bulk := rest.BulkCreateEventRequest{Events: make([]rest.CreateEventRequest, 0, len(req.Events))}
+	for i, ev := range req.Events {
+		prefix := fmt.Sprintf("events[%d].", i)
+		if ev.Key == "" {
+			problems.invalid = append(problems.invalid, FieldError{Field: prefix + "key", Message: "must not be empty"})
+			continue
+		}
+		bulk.Events = append(bulk.Events, problems.check(cfg, a.client.Namespace(), prefix, ev))
+	}
+	if problems.any() {
+		return problems.respond(c)
+	}
+
+	tenantID, err := uuid.Parse(a.client.TenantId())
+	if err != nil {
+		return a.eventsFailed(c, fmt.Errorf("invalid tenant ID %q: %w", a.client.TenantId(), err))
+	}
+	resp, err := a.client.API().EventCreateBulkWithResponse(c.Request().Context(), tenantID, bulk)
+	if err != nil {
+		return a.eventsFailed(c, err)
+	}
+	if resp.JSON200 == nil {
+		return a.hatchetRejected(c, resp.StatusCode(), resp.JSON400, resp.Status())
+	}
+
+	// Hatchet answers in the order of the request, with namespaced keys
+	out := BulkEventResponse{Events: make([]EventResponse, 0, len(resp.JSON200.Events))}
+	for i, ev := range resp.JSON200.Events {
+		key := ev.Key
+		if i < len(req.Events) {
+			key = req.Events[i].Key
+		}
+		out.Events = append(out.Events, EventResponse{EventID: ev.Metadata.Id, Key: key})
+	}
+	a.logger.InfoContext(c.Request().Context(), "events pushed", "events", len(out.Events))
+	return c.JSON(http.StatusAccepted, out)
+}
+
+// check validates one event, recording problems under fields starting with prefix, and converts it
+// for the REST API. The allowlist applies to the key as given, before namespace is applied.
+func (p *eventProblems) check(cfg *config.AppConfig, namespace, prefix string, ev EventRequest) rest.CreateEventRequest {
+	if !keyAllowed(ev.Key, cfg.EventKeys) {
+		p.notAllowed = append(p.notAllowed, FieldError{Field: prefix + "key", Message: fmt.Sprintf("event key %q is not in EVENT_KEYS", ev.Key)})
+	}
+	if len(ev.Payload) > cfg.EventMaxPayloadBytes {
+		p.tooLarge = append(p.tooLarge, FieldError{Field: prefix + "payload", Message: fmt.Sprintf("is %d bytes, over the %d byte limit", len(ev.Payload), cfg.EventMaxPayloadBytes)})
+	}
+	if ev.Priority != nil && (*ev.Priority < minPriority || *ev.Priority > maxPriority) {
+		p.invalid = append(p.invalid, FieldError{Field: prefix + "priority", Message: fmt.Sprintf("must be between %d and %d", minPriority, maxPriority)})
+	}
+
+	data := map[string]any{}
+	if len(bytes.TrimSpace(ev.Payload)) > 0 {
+		// UseNumber keeps large integers exact on the way through
+		dec := json.NewDecoder(bytes.NewReader(ev.Payload))
+		dec.UseNumber()
+		if err := dec.Decode(&data); err != nil || data == nil {
+			p.invalid = append(p.invalid, FieldError{Field: prefix + "payload", Message: "must be a JSON object"})
+		}
+	}
+
+	out := rest.CreateEventRequest{Key: clientconfig.ApplyNamespace(ev.Key, &namespace), Data: data, Priority: ev.Priority}
+	if len(ev.Metadata) > 0 {
+		metadata := make(map[string]any, len(ev.Metadata))
+		for k, v := range ev.Metadata {
+			metadata[k] = v
+		}
+		out.AdditionalMetadata = &metadata
+	}
+	return out
+}
+
+func (p *eventProblems) any() bool {
+	return len(p.tooLarge)+len(p.notAllowed)+len(p.invalid) > 0
+}
+
+// respond writes the error response for the problems found: 413 for oversized payloads first, then 403
+// for keys outside the allowlist, then 400
+func (p *eventProblems) respond(c echo.Context) error {
+	switch {
+	case len(p.tooLarge) > 0:
+		return fail(c, http.StatusRequestEntityTooLarge, CodePayloadTooLarge, "event payload too large", p.tooLarge...)
+	case len(p.notAllowed) > 0:
+		return fail(c, http.StatusForbidden, CodeKeyNotAllowed, "event key not allowed", p.notAllowed...)
+	}
+	return fail(c, http.StatusBadRequest, CodeValidationFailed, "request failed validation", p.invalid...)
+}
+
+// keyAllowed reports whether key matches one of the allowlist's path.Match patterns
+func keyAllowed(key string, allowlist []string) bool {
+	for _, pattern := range allowlist {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hatchetRejected maps a REST response without a result to an error response
+func (a *API) hatchetRejected(c echo.Context, status int, errs *rest.APIErrors, statusText string) error {
+	switch status {
+	case http.StatusBadRequest:
+		var msgs []string
+		if errs != nil {
+			for _, e := range errs.Errors {
+				msgs = append(msgs, e.Description)
+			}
+		}
+		return invalid(c, "hatchet rejected the event: "+strings.Join(msgs, "; "))
+	case http.StatusTooManyRequests:
+		return fail(c, http.StatusTooManyRequests, CodeRateLimited, "hatchet is rate limiting events")
+	}
+	return a.eventsFailed(c, fmt.Errorf("unexpected response: %s", statusText))
+}
+
+func (a *API) eventsFailed(c echo.Context, err error) error {
+	a.logger.ErrorContext(c.Request().Context(), "failed to push events", "error", err)
+	return fail(c, http.StatusBadGateway, CodeHatchetError, "failed to push events")
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hatchet-dev/hatchet/pkg/client/rest"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEvents serves the event create endpoints of the Hatchet REST API, numbering the events it creates
+type fakeEvents struct {
+	status  int
+	created []rest.CreateEventRequest
+}
+
+func (f *fakeEvents) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.status != 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(f.status)
+		_ = json.NewEncoder(w).Encode(rest.APIErrors{Errors: []rest.APIError{{Description: "bad event"}}})
+		return
+	}
+
+	var events []rest.CreateEventRequest
+	if strings.HasSuffix(r.URL.Path, "/bulk") {
+		var bulk rest.BulkCreateEventRequest
+		_ = json.NewDecoder(r.Body).Decode(&bulk)
+		events = bulk.Events
+	} else {
+		var ev rest.CreateEventRequest
+		_ = json.NewDecoder(r.Body).Decode(&ev)
+		events = []rest.CreateEventRequest{ev}
+	}
+
+	var out rest.Events
+	for _, ev := range events {
+		f.created = append(f.created, ev)
+		out.Events = append(out.Events, rest.Event{Key: ev.Key, Metadata: rest.APIResourceMeta{Id: "event-" + string(rune('0'+len(f.created)))}})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if strings.HasSuffix(r.URL.Path, "/bulk") {
+		_ = json.NewEncoder(w).Encode(out)
+	} else {
+		_ = json.NewEncoder(w).Encode(out.Events[0])
+	}
+}
+
+func newEventsServer(t *testing.T, hatchet *fakeEvents) *echo.Echo {
+	t.Helper()
+	e := echo.New()
+	New(&fakeClient{rest: fakeREST(t, hatchet)}, testConfig, nil, discard).Register(e)
+	return e
+}
+
+func TestPushEvent(t *testing.T) {
+	hatchet := &fakeEvents{}
+	e := newEventsServer(t, hatchet)
+
+	rec := post(e, "/api/v1/events/order:created", `{"payload": {"orderId": 9007199254740993}, "metadata": {"source": "shop"}}`)
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	var resp EventResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, EventResponse{EventID: "event-1", Key: "order:created"}, resp)
+
+	require.Len(t, hatchet.created, 1)
+	assert.Equal(t, "order:created", hatchet.created[0].Key)
+	assert.Equal(t, map[string]any{"source": "shop"}, *hatchet.created[0].AdditionalMetadata)
+}
+
+func TestPushEventRefused(t *testing.T) {
+	hatchet := &fakeEvents{}
+	e := newEventsServer(t, hatchet)
+
+	rec := post(e, "/api/v1/events/user:deleted", `{}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, CodeKeyNotAllowed, decodeError(t, rec).Code)
+
+	rec = post(e, "/api/v1/events/order:created", `{"payload": {"note": "`+strings.Repeat("x", 100)+`"}}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, "payload", decodeError(t, rec).Fields[0].Field)
+
+	rec = post(e, "/api/v1/events/order:created", `{"payload": "`+strings.Repeat("x", 20<<10)+`"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code, "the body is cut off before it is read in full")
+
+	rec = post(e, "/api/v1/events/order:created", `{"payload": [1]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, CodeValidationFailed, decodeError(t, rec).Code)
+
+	assert.Empty(t, hatchet.created)
+
+	e = newEventsServer(t, &fakeEvents{status: http.StatusTooManyRequests})
+	rec = post(e, "/api/v1/events/order:created", `{}`)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, CodeRateLimited, decodeError(t, rec).Code)
+}
+
+func TestPushEventsBulk(t *testing.T) {
+	hatchet := &fakeEvents{}
+	e := newEventsServer(t, hatchet)
+
+	rec := post(e, "/api/v1/events", `{"events": [{"key": "order:created", "payload": {"n": 1}}, {"key": "order:paid"}]}`)
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	var resp BulkEventResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, []EventResponse{{EventID: "event-1", Key: "order:created"}, {EventID: "event-2", Key: "order:paid"}}, resp.Events)
+
+	// One bad event stops the whole push
+	rec = post(e, "/api/v1/events", `{"events": [{"key": "order:created"}, {"key": "user:deleted"}]}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "events[1].key", decodeError(t, rec).Fields[0].Field)
+
+	rec = post(e, "/api/v1/events", `{"events": [{"key": "order:a"}, {"key": "order:b"}, {"key": "order:c"}]}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code, "over EventMaxBulkEvents")
+
+	assert.Len(t, hatchet.created, 2)
+}
+
+func TestPushEventsNamespace(t *testing.T) {
+	hatchet := &fakeEvents{}
+	e := echo.New()
+	New(&fakeClient{rest: fakeREST(t, hatchet), namespace: "staging_"}, testConfig, nil, discard).Register(e)
+
+	rec := post(e, "/api/v1/events/order:created", `{}`)
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	var resp EventResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "order:created", resp.Key, "answered with the key as given")
+
+	rec = post(e, "/api/v1/events", `{"events": [{"key": "order:paid"}]}`)
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	var bulk BulkEventResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &bulk))
+	assert.Equal(t, "order:paid", bulk.Events[0].Key)
+
+	require.Len(t, hatchet.created, 2)
+	assert.Equal(t, "staging_order:created", hatchet.created[0].Key, "namespaced as Event().Push would")
+	assert.Equal(t, "staging_order:paid", hatchet.created[1].Key)
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/arun0009/hatchetest/pkg/runs"
+	"github.com/google/uuid"
+	"github.com/hatchet-dev/hatchet/pkg/client/rest"
+	"github.com/labstack/echo/v4"
+)
+
+// MaxWait is the longest a request may long-poll with ?wait=
+const MaxWait = time.Minute
+
+// Run is the body of GET /api/v1/runs/:id
+type Run struct {
+	ID          string            `json:"id"`
+	DisplayName string            `json:"displayName"`
+	Status      rest.V1TaskStatus `json:"status"`
+	CreatedAt   *time.Time        `json:"createdAt,omitempty"`
+	StartedAt   *time.Time        `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time        `json:"finishedAt,omitempty"`
+	DurationMs  *int              `json:"durationMs,omitempty"`
+	// Error joins the run's error and the errors of its failed steps
+	Error    string         `json:"error,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+	Steps    []Step         `json:"steps"`
+}
+
+// Step is one step of a run
+type Step struct {
+	Name       string            `json:"name"`
+	Status     rest.V1TaskStatus `json:"status"`
+	Attempt    *int              `json:"attempt,omitempty"`
+	StartedAt  *time.Time        `json:"startedAt,omitempty"`
+	FinishedAt *time.Time        `json:"finishedAt,omitempty"`
+	DurationMs *int              `json:"durationMs,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Output     map[string]any    `json:"output,omitempty"`
+}
+
+// Result is the body of GET /api/v1/runs/:id/result
+type Result struct {
+	ID       string            `json:"id"`
+	Status   rest.V1TaskStatus `json:"status"`
+	Finished bool              `json:"finished"`
+	// Output holds each step's output, keyed by step name
+	Output map[string]map[string]any `json:"output,omitempty"`
+	Error  string                    `json:"error,omitempty"`
+}
+
+// badParam is a path or query parameter that failed validation
+type badParam FieldError
+
+func (e *badParam) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// getRun answers with the run's status, steps and timings
+func (a *API) getRun(c echo.Context) error {
+	details, err := a.lookupRun(c)
+	if err != nil {
+		return a.runFailed(c, err)
+	}
+	return c.JSON(http.StatusOK, newRun(details))
+}
+
+// getRunResult answers 200 with the run's outputs once it has finished, and 202 while it is still going
+func (a *API) getRunResult(c echo.Context) error {
+	details, err := a.lookupRun(c)
+	if err != nil {
+		return a.runFailed(c, err)
+	}
+
+	result := Result{
+		ID:       details.Run.Metadata.Id,
+		Status:   details.Run.Status,
+		Finished: runs.IsTerminal(details.Run.Status),
+		Error:    runs.ErrorMessage(details),
+	}
+	for _, task := range details.Tasks {
+		if len(task.Output) > 0 {
+			if result.Output == nil {
+				result.Output = map[string]map[string]any{}
+			}
+			result.Output[runs.StepName(task)] = task.Output
+		}
+	}
+
+	if !result.Finished {
+		return c.JSON(http.StatusAccepted, result)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// lookupRun fetches the run named in the path. With ?wait= it first waits, up to that long, for the run
+// to finish; if it does not, or the server starts shutting down, the run is returned as it is.
+func (a *API) lookupRun(c echo.Context) (*rest.V1WorkflowRunDetails, error) {
+	runID := c.Param("id")
+	if _, err := uuid.Parse(runID); err != nil {
+		return nil, &badParam{Field: "id", Message: "must be a UUID"}
+	}
+	wait, err := parseWait(c.QueryParam("wait"))
+	if err != nil {
+		return nil, err
+	}
+
+	if wait > 0 {
+		ctx, cancel := a.requestContext(c)
+		ctx, cancelWait := context.WithTimeout(ctx, wait)
+		details, err := runs.Wait(ctx, a.client, runID)
+		waited := ctx.Err()
+		cancelWait()
+		cancel()
+		if waited == nil {
+			return details, err
+		}
+	}
+	return runs.Details(c.Request().Context(), a.client, runID)
+}
+
+// parseWait reads the ?wait= duration, which may be empty for no wait
+func parseWait(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 || d > MaxWait {
+		return 0, &badParam{Field: "wait", Message: fmt.Sprintf("must be a duration from 0s to %s, such as 30s", MaxWait)}
+	}
+	return d, nil
+}
+
+// runFailed maps an error from lookupRun to a response
+func (a *API) runFailed(c echo.Context, err error) error {
+	var param *badParam
+	if errors.As(err, &param) {
+		return fail(c, http.StatusBadRequest, CodeValidationFailed, "request failed validation", FieldError(*param))
+	}
+	if errors.Is(err, runs.ErrRunNotFound) {
+		return fail(c, http.StatusNotFound, CodeRunNotFound, fmt.Sprintf("run %s not found", c.Param("id")))
+	}
+	a.logger.ErrorContext(c.Request().Context(), "failed to get workflow run", "run_id", c.Param("id"), "error", err)
+	return fail(c, http.StatusBadGateway, CodeHatchetError, "failed to get workflow run")
+}
+
+func newRun(details *rest.V1WorkflowRunDetails) Run {
+	run := Run{
+		ID:          details.Run.Metadata.Id,
+		DisplayName: details.Run.DisplayName,
+		Status:      details.Run.Status,
+		CreatedAt:   details.Run.CreatedAt,
+		StartedAt:   details.Run.StartedAt,
+		FinishedAt:  details.Run.FinishedAt,
+		DurationMs:  details.Run.Duration,
+		Error:       runs.ErrorMessage(details),
+		Steps:       make([]Step, 0, len(details.Tasks)),
+	}
+	if details.Run.AdditionalMetadata != nil {
+		run.Metadata = *details.Run.AdditionalMetadata
+	}
+	for _, task := range details.Tasks {
+		step := Step{
+			Name:       runs.StepName(task),
+			Status:     task.Status,
+			Attempt:    task.Attempt,
+			StartedAt:  task.StartedAt,
+			FinishedAt: task.FinishedAt,
+			DurationMs: task.Duration,
+			Output:     task.Output,
+		}
+		if task.ErrorMessage != nil {
+			step.Error = *task.ErrorMessage
+		}
+		run.Steps = append(run.Steps, step)
+	}
+	return run
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hatchet-dev/hatchet/pkg/client/rest"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRunID     = "5d2d8a4e-8f0b-4a39-9a8c-3f6f0d3f4b11"
+	testStepRunID = "0b9e4c1e-3f43-4f4e-a3a4-5a0d7c1e2f60"
+)
+
+// testStartedAt is when the fake run's step started
+var testStartedAt = time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+
+// fakeHatchet serves the workflow run endpoints of the Hatchet REST API. The run reports RUNNING until
+// finishAfter status lookups have been made, then COMPLETED.
+type fakeHatchet struct {
+	finishAfter int32
+	lookups     atomic.Int32
+}
+
+func (f *fakeHatchet) status() rest.V1TaskStatus {
+	if f.lookups.Load() >= f.finishAfter {
+		return rest.V1TaskStatusCOMPLETED
+	}
+	return rest.V1TaskStatusRUNNING
+}
+
+func (f *fakeHatchet) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const prefix = "/api/v1/stable/workflow-runs/"
+	id, suffix, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	if id != testRunID {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	if suffix == "status" {
+		f.lookups.Add(1)
+		_ = json.NewEncoder(w).Encode(f.status())
+		return
+	}
+
+	actionID := "order-workflow:charge-card"
+	duration := 1200
+	status := f.status()
+	task := rest.V1TaskSummary{
+		ActionId:       &actionID,
+		TaskExternalId: uuid.MustParse(testStepRunID),
+		Status:         status,
+		StartedAt:      &testStartedAt,
+		Duration:       &duration,
+	}
+	run := rest.V1WorkflowRun{
+		Metadata:           rest.APIResourceMeta{Id: testRunID},
+		DisplayName:        "order-workflow-1760000000",
+		Status:             status,
+		AdditionalMetadata: &map[string]any{"source": "checkout"},
+	}
+	if status == rest.V1TaskStatusCOMPLETED {
+		finishedAt := testStartedAt.Add(2 * time.Second)
+		task.Output, task.FinishedAt, run.FinishedAt = map[string]any{"charged": true}, &finishedAt, &finishedAt
+	}
+	_ = json.NewEncoder(w).Encode(rest.V1WorkflowRunDetails{Run: run, Tasks: []rest.V1TaskSummary{task}})
+}
+
+// fakeREST returns a REST client for a fake Hatchet API
+func fakeREST(t *testing.T, hatchet http.Handler) *rest.ClientWithResponses {
+	t.Helper()
+	srv := httptest.NewServer(hatchet)
+	t.Cleanup(srv.Close)
+	restClient, err := rest.NewClientWithResponses(srv.URL)
+	require.NoError(t, err)
+	return restClient
+}
+
+func newRunsServer(t *testing.T, hatchet *fakeHatchet) *echo.Echo {
+	t.Helper()
+	e := echo.New()
+	New(&fakeClient{rest: fakeREST(t, hatchet)}, testConfig, nil, discard).Register(e)
+	return e
+}
+
+func get(e *echo.Echo, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestGetRun(t *testing.T) {
+	e := newRunsServer(t, &fakeHatchet{finishAfter: 100})
+
+	rec := get(e, "/api/v1/runs/"+testRunID)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var run Run
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &run))
+	assert.Equal(t, testRunID, run.ID)
+	assert.Equal(t, rest.V1TaskStatusRUNNING, run.Status)
+	assert.Equal(t, "checkout", run.Metadata["source"])
+	require.Len(t, run.Steps, 1)
+	assert.Equal(t, "charge-card", run.Steps[0].Name)
+	assert.Equal(t, 1200, *run.Steps[0].DurationMs)
+
+	rec = get(e, "/api/v1/runs/7f1f2c1a-0000-4000-8000-000000000000")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, CodeRunNotFound, decodeError(t, rec).Code)
+
+	rec = get(e, "/api/v1/runs/not-a-uuid")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = get(e, "/api/v1/runs/"+testRunID+"?wait=2h")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "wait", decodeError(t, rec).Fields[0].Field)
+}
+
+func TestGetRunResultWaits(t *testing.T) {
+	e := newRunsServer(t, &fakeHatchet{finishAfter: 2})
+
+	rec := get(e, "/api/v1/runs/"+testRunID+"/result")
+	assert.Equal(t, http.StatusAccepted, rec.Code, "still running without a wait")
+
+	rec = get(e, "/api/v1/runs/"+testRunID+"/result?wait=5s")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var result Result
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	assert.True(t, result.Finished)
+	assert.Equal(t, rest.V1TaskStatusCOMPLETED, result.Status)
+	assert.Equal(t, map[string]any{"charged": true}, result.Output["charge-card"])
+}
+
+func TestGetRunResultWaitRunsOut(t *testing.T) {
+	e := newRunsServer(t, &fakeHatchet{finishAfter: 1000})
+
+	start := time.Now()
+	rec := get(e, "/api/v1/runs/"+testRunID+"/result?wait=300ms")
+	assert.Equal(t, http.StatusAccepted, rec.Code, "the run as it is when the wait runs out")
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestLongPollEndsOnShutdown(t *testing.T) {
+	e := newRunsServer(t, &fakeHatchet{finishAfter: 1000})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- get(e, "/api/v1/runs/"+testRunID+"/result?wait=30s")
+	}()
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, e.Shutdown(context.Background()))
+
+	select {
+	case rec := <-done:
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+	case <-time.After(5 * time.Second):
+		t.Fatal("long-poll did not end on shutdown")
+	}
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Schemas holds a JSON schema for each workflow's input. Schemas are written in the OpenAPI 3 dialect
+// of JSON Schema: type, properties, required, enum, minimum, pattern and so on.
+type Schemas struct {
+	mu      sync.RWMutex
+	schemas map[string]*openapi3.Schema
+}
+
+// NewSchemas returns an empty registry
+func NewSchemas() *Schemas {
+	return &Schemas{schemas: map[string]*openapi3.Schema{}}
+}
+
+// Register parses schema and uses it for the input of workflow, replacing any earlier one
+func (s *Schemas) Register(workflow string, schema []byte) error {
+	var parsed openapi3.Schema
+	if err := json.Unmarshal(schema, &parsed); err != nil {
+		return fmt.Errorf("invalid schema for workflow %s: %w", workflow, err)
+	}
+	if err := parsed.Validate(context.Background()); err != nil {
+		return fmt.Errorf("invalid schema for workflow %s: %w", workflow, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schemas[workflow] = &parsed
+	return nil
+}
+
+// LoadDir registers every <workflow>.json file in dir as the schema of that workflow
+func (s *Schemas) LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := s.Register(strings.TrimSuffix(filepath.Base(path), ".json"), data); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks input, decoded from JSON, against the workflow's schema and returns every problem
+// found. A workflow without a schema accepts any input.
+func (s *Schemas) Validate(workflow string, input any) []FieldError {
+	s.mu.RLock()
+	schema, ok := s.schemas[workflow]
+	s.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	err := schema.VisitJSON(input, openapi3.MultiErrors())
+	if err == nil {
+		return nil
+	}
+	var fields []FieldError
+	for _, err := range flatten(err) {
+		field := FieldError{Field: "input", Message: err.Error()}
+		var serr *openapi3.SchemaError
+		if errors.As(err, &serr) {
+			if path := serr.JSONPointer(); len(path) > 0 {
+				field.Field += "." + strings.Join(path, ".")
+			}
+			field.Message = serr.Reason
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// flatten expands the nested MultiErrors VisitJSON returns for objects and arrays
+func flatten(err error) []error {
+	var multi openapi3.MultiError
+	if !errors.As(err, &multi) {
+		return []error{err}
+	}
+	var errs []error
+	for _, err := range multi {
+		errs = append(errs, flatten(err)...)
+	}
+	return errs
+}
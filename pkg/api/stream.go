@@ -0,0 +1,348 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arun0009/hatchetest/pkg/runs"
+	"github.com/google/uuid"
+	"github.com/hatchet-dev/hatchet/pkg/client"
+	"github.com/hatchet-dev/hatchet/pkg/client/rest"
+	"github.com/labstack/echo/v4"
+)
+
+// HeartbeatInterval is how often an idle event stream gets a comment line, so proxies keep it open
+const HeartbeatInterval = 15 * time.Second
+
+// Names of the events on GET /api/v1/runs/:id/events. Step events are step_ followed by Hatchet's event
+// type: step_started, step_completed, step_failed, step_cancelled or step_timed_out.
+const (
+	EventStream      = "stream"
+	EventRunFinished = "run_finished"
+	EventError       = "error"
+)
+
+const headerLastEventID = "Last-Event-ID"
+
+// RunEvent is the data of an event on GET /api/v1/runs/:id/events
+type RunEvent struct {
+	RunID     string `json:"runId"`
+	StepRunID string `json:"stepRunId,omitempty"`
+	// Step is the step's name, when the step had started by the time the stream was opened
+	Step      string     `json:"step,omitempty"`
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+	// Status is the run's final status, on run_finished
+	Status     rest.V1TaskStatus `json:"status,omitempty"`
+	RetryCount *int32            `json:"retryCount,omitempty"`
+	// Payload is the step output on step_completed, the error on step_failed and the data on stream
+	Payload json.RawMessage `json:"payload,omitempty"`
+	// Message explains an error event
+	Message string `json:"message,omitempty"`
+}
+
+// sseEvent is one server-sent event. The ID is the event's time in Unix microseconds, so a client that
+// reconnects with Last-Event-ID is sent only what happened after it.
+type sseEvent struct {
+	id   string
+	name string
+	data RunEvent
+}
+
+// key identifies a step event for deduplication between the catch-up and the live subscription. A retried
+// step keeps its step run ID, so the attempt is part of the key.
+func (ev sseEvent) key() string {
+	if ev.data.StepRunID == "" {
+		return ""
+	}
+	var retry int32
+	if ev.data.RetryCount != nil {
+		retry = *ev.data.RetryCount
+	}
+	return fmt.Sprintf("%s/%s/%d", ev.name, ev.data.StepRunID, retry)
+}
+
+// subscription is what one of the Hatchet subscriptions ended with
+type subscription struct {
+	stream bool
+	err    error
+}
+
+// streamRun sends the run's progress as server-sent events until the run finishes or the client goes away.
+// Hatchet only streams live events, so each connection starts with the step transitions recorded in the
+// REST API after Last-Event-ID (or all of them), and stream data sent while no client was connected is lost.
+func (a *API) streamRun(c echo.Context) error {
+	runID := c.Param("id")
+	if _, err := uuid.Parse(runID); err != nil {
+		return a.runFailed(c, &badParam{Field: "id", Message: "must be a UUID"})
+	}
+	var after time.Time
+	if id, err := strconv.ParseInt(c.Request().Header.Get(headerLastEventID), 10, 64); err == nil {
+		after = time.UnixMicro(id)
+	}
+
+	ctx, cancel := a.requestContext(c)
+	defer cancel()
+
+	// Subscribe before reading the run, so nothing falls between the catch-up and the live events
+	live := make(chan sseEvent)
+	ended := make(chan subscription, 2)
+	send := func(ev sseEvent) error {
+		select {
+		case live <- ev:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	go func() {
+		err := a.client.Subscribe().On(ctx, runID, func(event client.WorkflowEvent) error {
+			if ev, ok := fromWorkflowEvent(event); ok {
+				return send(ev)
+			}
+			return nil
+		})
+		ended <- subscription{err: err}
+	}()
+	go func() {
+		err := a.client.Subscribe().Stream(ctx, runID, func(event client.StreamEvent) error {
+			return send(sseEvent{name: EventStream, data: RunEvent{RunID: runID, Payload: payload(string(event.Message))}})
+		})
+		ended <- subscription{stream: true, err: err}
+	}()
+
+	// A run that was not recorded yet has no catch-up; Hatchet's subscription waits for it
+	details, err := runs.Details(ctx, a.client, runID)
+	if err != nil && !errors.Is(err, runs.ErrRunNotFound) {
+		return a.runFailed(c, err)
+	}
+	var finished *sseEvent
+	if details != nil && runs.IsTerminal(details.Run.Status) {
+		ev := runFinished(runID, details)
+		if !after.IsZero() && ev.data.Timestamp != nil && !ev.data.Timestamp.After(after) {
+			// The client saw the run finish; 204 stops EventSource reconnecting
+			return c.NoContent(http.StatusNoContent)
+		}
+		finished = &ev
+	}
+
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set(echo.HeaderCacheControl, "no-cache")
+	w.Header().Set(echo.HeaderConnection, "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	w.Flush()
+
+	sent := map[string]bool{}
+	steps := map[string]string{}
+	if details != nil {
+		for _, ev := range catchUp(runID, details, after) {
+			if err := writeEvent(w, ev); err != nil {
+				return nil
+			}
+			sent[ev.key()] = true
+		}
+		for _, task := range details.Tasks {
+			steps[task.TaskExternalId.String()] = runs.StepName(task)
+		}
+	}
+	if finished != nil {
+		_ = writeEvent(w, *finished)
+		return nil
+	}
+
+	heartbeat := time.NewTicker(a.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+			w.Flush()
+
+		case ev := <-live:
+			// Events up to Last-Event-ID reached the client before it reconnected
+			if ev.data.Timestamp != nil && !ev.data.Timestamp.After(after) {
+				continue
+			}
+			if key := ev.key(); key != "" {
+				if sent[key] {
+					continue
+				}
+				sent[key] = true
+			}
+			if ev.data.Step == "" {
+				ev.data.Step = steps[ev.data.StepRunID]
+			}
+			if err := writeEvent(w, ev); err != nil || ev.name == EventRunFinished {
+				return nil
+			}
+
+		case sub := <-ended:
+			if sub.err != nil && ctx.Err() == nil {
+				a.logger.WarnContext(ctx, "workflow run subscription failed", "run_id", runID, "stream", sub.stream, "error", sub.err)
+				_ = writeEvent(w, sseEvent{name: EventError, data: RunEvent{RunID: runID, Message: sub.err.Error()}})
+				return nil
+			}
+			if sub.stream {
+				continue
+			}
+			// Hatchet hung up, which it does once the run has finished
+			if details, err := runs.Details(ctx, a.client, runID); err == nil && runs.IsTerminal(details.Run.Status) {
+				_ = writeEvent(w, runFinished(runID, details))
+			}
+			return nil
+		}
+	}
+}
+
+// writeEvent writes one event in the text/event-stream format and flushes it
+func writeEvent(w *echo.Response, ev sseEvent) error {
+	data, err := json.Marshal(ev.data)
+	if err != nil {
+		return err
+	}
+	if ev.id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", ev.id); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.name, data); err != nil {
+		return err
+	}
+	w.Flush()
+	return nil
+}
+
+// fromWorkflowEvent converts an event from Hatchet's run subscription
+func fromWorkflowEvent(event client.WorkflowEvent) (sseEvent, bool) {
+	kind := strings.ToLower(strings.TrimPrefix(event.EventType.String(), "RESOURCE_EVENT_TYPE_"))
+	ev := sseEvent{data: RunEvent{RunID: event.WorkflowRunId, RetryCount: event.RetryCount, Payload: payload(event.EventPayload)}}
+	if event.EventTimestamp != nil {
+		at := event.EventTimestamp.AsTime()
+		ev.id, ev.data.Timestamp = eventID(at), &at
+	}
+
+	switch {
+	case kind == "unknown":
+		return ev, false
+	case event.ResourceType.String() == "RESOURCE_TYPE_STEP_RUN":
+		ev.name, ev.data.StepRunID = "step_"+kind, event.ResourceId
+		return ev, true
+	case event.ResourceType.String() == "RESOURCE_TYPE_WORKFLOW_RUN" && kind != "started":
+		ev.name, ev.data.Status = EventRunFinished, runStatus(kind)
+		if ev.data.Status != rest.V1TaskStatusCOMPLETED {
+			ev.data.Message, ev.data.Payload = event.EventPayload, nil
+		}
+		if kind == "timed_out" && ev.data.Message == "" {
+			ev.data.Message = "run timed out"
+		}
+		return ev, true
+	default:
+		return ev, false
+	}
+}
+
+// runStatus maps the event type that finished a run to the status the REST API reports for it. The REST
+// API has no timed out status; a run that timed out is FAILED.
+func runStatus(kind string) rest.V1TaskStatus {
+	switch kind {
+	case "completed":
+		return rest.V1TaskStatusCOMPLETED
+	case "cancelled":
+		return rest.V1TaskStatusCANCELLED
+	default:
+		return rest.V1TaskStatusFAILED
+	}
+}
+
+// catchUp lists the step transitions the REST API recorded after the given time, oldest first
+func catchUp(runID string, details *rest.V1WorkflowRunDetails, after time.Time) []sseEvent {
+	var events []sseEvent
+	add := func(name string, task rest.V1TaskSummary, at *time.Time, data json.RawMessage) {
+		if at == nil || !at.After(after) {
+			return
+		}
+		events = append(events, sseEvent{id: eventID(*at), name: name, data: RunEvent{
+			RunID:      runID,
+			StepRunID:  task.TaskExternalId.String(),
+			Step:       runs.StepName(task),
+			Timestamp:  at,
+			RetryCount: retryCount(task),
+			Payload:    data,
+		}})
+	}
+
+	for _, task := range details.Tasks {
+		add("step_started", task, task.StartedAt, nil)
+		switch task.Status {
+		case rest.V1TaskStatusCOMPLETED:
+			out, _ := json.Marshal(task.Output)
+			add("step_completed", task, task.FinishedAt, out)
+		case rest.V1TaskStatusFAILED, rest.V1TaskStatusCANCELLED:
+			var msg json.RawMessage
+			if task.ErrorMessage != nil {
+				msg = payload(*task.ErrorMessage)
+			}
+			add("step_"+strings.ToLower(string(task.Status)), task, task.FinishedAt, msg)
+		}
+	}
+	slices.SortStableFunc(events, func(a, b sseEvent) int {
+		return a.data.Timestamp.Compare(*b.data.Timestamp)
+	})
+	return events
+}
+
+// retryCount is the retries a task has had, which Hatchet's live events carry as RetryCount. The REST API
+// reports the task's latest attempt only, so earlier attempts are not part of the catch-up.
+func retryCount(task rest.V1TaskSummary) *int32 {
+	var n int
+	switch {
+	case task.RetryCount != nil:
+		n = *task.RetryCount
+	case task.Attempt != nil && *task.Attempt > 0:
+		n = *task.Attempt - 1
+	}
+	count := int32(n)
+	return &count
+}
+
+// runFinished is the run_finished event of a run the REST API reports as finished
+func runFinished(runID string, details *rest.V1WorkflowRunDetails) sseEvent {
+	ev := sseEvent{name: EventRunFinished, data: RunEvent{
+		RunID:     runID,
+		Status:    details.Run.Status,
+		Timestamp: details.Run.FinishedAt,
+		Message:   runs.ErrorMessage(details),
+	}}
+	if details.Run.FinishedAt != nil {
+		ev.id = eventID(*details.Run.FinishedAt)
+	}
+	return ev
+}
+
+func eventID(at time.Time) string {
+	return strconv.FormatInt(at.UnixMicro(), 10)
+}
+
+// payload embeds s as JSON if it is JSON, and as a JSON string otherwise
+func payload(s string) json.RawMessage {
+	if s == "" {
+		return nil
+	}
+	if json.Valid([]byte(s)) {
+		return json.RawMessage(s)
+	}
+	quoted, _ := json.Marshal(s)
+	return quoted
+}
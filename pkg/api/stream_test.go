@@ -0,0 +1,241 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hatchet-dev/hatchet/pkg/client"
+	"github.com/hatchet-dev/hatchet/pkg/client/rest"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeSubscribe plays back run events. On sends its events once Stream has sent its messages, then
+// hangs up unless hold is set; Stream stays open until the request ends.
+type fakeSubscribe struct {
+	client.SubscribeClient
+	events   []client.WorkflowEvent
+	messages []string
+	hold     bool
+
+	streamed     chan struct{}
+	streamedOnce sync.Once
+}
+
+func (f *fakeSubscribe) On(ctx context.Context, _ string, handler client.RunHandler) error {
+	select {
+	case <-f.streamed:
+	case <-ctx.Done():
+		return nil
+	}
+	for _, ev := range f.events {
+		if err := handler(ev); err != nil {
+			return err
+		}
+	}
+	if f.hold {
+		<-ctx.Done()
+	}
+	return nil
+}
+
+func (f *fakeSubscribe) Stream(ctx context.Context, _ string, handler client.StreamHandler) error {
+	for _, msg := range f.messages {
+		if err := handler(client.StreamEvent{Message: []byte(msg)}); err != nil {
+			return err
+		}
+	}
+	f.streamedOnce.Do(func() { close(f.streamed) })
+	<-ctx.Done()
+	return nil
+}
+
+// workflowEvent builds an event of Hatchet's run subscription from its protojson form. Its type lives in an
+// internal SDK package, so it is made through reflection.
+func workflowEvent(t *testing.T, js string) client.WorkflowEvent {
+	t.Helper()
+	typ := reflect.TypeOf(client.WorkflowEvent(nil))
+	msg := reflect.New(typ.Elem()).Interface().(proto.Message)
+	require.NoError(t, protojson.Unmarshal([]byte(js), msg))
+	return reflect.ValueOf(msg).Convert(typ).Interface().(client.WorkflowEvent)
+}
+
+type received struct {
+	id, name string
+	data     RunEvent
+}
+
+// readEvents parses a text/event-stream body
+func readEvents(t *testing.T, body string) []received {
+	t.Helper()
+	var events []received
+	var ev received
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			ev.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			ev.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev.data))
+		case line == "" && ev.name != "":
+			events = append(events, ev)
+			ev = received{}
+		}
+	}
+	return events
+}
+
+func newStreamServer(t *testing.T, hatchet *fakeHatchet, sub *fakeSubscribe, heartbeat time.Duration) *echo.Echo {
+	t.Helper()
+	sub.streamed = make(chan struct{})
+	a := New(&fakeClient{rest: fakeREST(t, hatchet), subscribe: sub}, testConfig, nil, discard)
+	a.heartbeat = heartbeat
+	e := echo.New()
+	a.Register(e)
+	return e
+}
+
+func streamRequest(e *echo.Echo, ctx context.Context, lastEventID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/runs/"+testRunID+"/events", nil).WithContext(ctx)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestStreamRun(t *testing.T) {
+	sub := &fakeSubscribe{
+		messages: []string{"charging card"},
+		events: []client.WorkflowEvent{
+			// Already sent from the catch-up
+			workflowEvent(t, `{"workflowRunId": "`+testRunID+`", "resourceType": "RESOURCE_TYPE_STEP_RUN",
+				"eventType": "RESOURCE_EVENT_TYPE_STARTED", "resourceId": "`+testStepRunID+`"}`),
+			workflowEvent(t, `{"workflowRunId": "`+testRunID+`", "resourceType": "RESOURCE_TYPE_STEP_RUN",
+				"eventType": "RESOURCE_EVENT_TYPE_COMPLETED", "resourceId": "`+testStepRunID+`",
+				"eventTimestamp": "2025-10-01T12:00:01Z", "eventPayload": "{\"charged\": true}"}`),
+			workflowEvent(t, `{"workflowRunId": "`+testRunID+`", "resourceType": "RESOURCE_TYPE_WORKFLOW_RUN",
+				"eventType": "RESOURCE_EVENT_TYPE_COMPLETED", "eventTimestamp": "2025-10-01T12:00:02Z", "hangup": true}`),
+		},
+		hold: true,
+	}
+	e := newStreamServer(t, &fakeHatchet{finishAfter: 1000}, sub, HeartbeatInterval)
+
+	rec := streamRequest(e, context.Background(), "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/event-stream", rec.Header().Get(echo.HeaderContentType))
+
+	events := readEvents(t, rec.Body.String())
+	require.Len(t, events, 4, rec.Body.String())
+
+	assert.Equal(t, "step_started", events[0].name)
+	assert.Equal(t, strconv.FormatInt(testStartedAt.UnixMicro(), 10), events[0].id)
+	assert.Equal(t, "charge-card", events[0].data.Step)
+
+	assert.Equal(t, EventStream, events[1].name)
+	assert.Equal(t, `"charging card"`, string(events[1].data.Payload))
+
+	assert.Equal(t, "step_completed", events[2].name)
+	assert.Equal(t, "charge-card", events[2].data.Step, "named from the catch-up")
+	assert.JSONEq(t, `{"charged": true}`, string(events[2].data.Payload))
+
+	assert.Equal(t, EventRunFinished, events[3].name)
+	assert.Equal(t, rest.V1TaskStatusCOMPLETED, events[3].data.Status)
+}
+
+func TestStreamRunRetriedStep(t *testing.T) {
+	stepEvent := func(eventType string, retryCount int, payload string) client.WorkflowEvent {
+		return workflowEvent(t, `{"workflowRunId": "`+testRunID+`", "resourceType": "RESOURCE_TYPE_STEP_RUN",
+			"eventType": "RESOURCE_EVENT_TYPE_`+eventType+`", "resourceId": "`+testStepRunID+`",
+			"retryCount": `+strconv.Itoa(retryCount)+`, "eventPayload": `+strconv.Quote(payload)+`}`)
+	}
+	sub := &fakeSubscribe{
+		events: []client.WorkflowEvent{
+			stepEvent("STARTED", 0, ""),
+			stepEvent("FAILED", 0, "card declined"),
+			stepEvent("STARTED", 1, ""),
+			stepEvent("COMPLETED", 1, `{"charged": true}`),
+			workflowEvent(t, `{"workflowRunId": "`+testRunID+`", "resourceType": "RESOURCE_TYPE_WORKFLOW_RUN",
+				"eventType": "RESOURCE_EVENT_TYPE_COMPLETED", "hangup": true}`),
+		},
+		hold: true,
+	}
+	e := newStreamServer(t, &fakeHatchet{finishAfter: 1000}, sub, HeartbeatInterval)
+
+	rec := streamRequest(e, context.Background(), "")
+	events := readEvents(t, rec.Body.String())
+
+	var names []string
+	for _, ev := range events {
+		names = append(names, ev.name)
+	}
+	assert.Equal(t, []string{"step_started", "step_failed", "step_started", "step_completed", EventRunFinished}, names,
+		"the first start comes from the catch-up; the retry's events are not taken for duplicates")
+	require.Len(t, events, 5)
+	assert.Equal(t, int32(1), *events[2].data.RetryCount)
+	assert.Equal(t, "charge-card", events[3].data.Step)
+}
+
+func TestStreamRunResumesAfterLastEventID(t *testing.T) {
+	sub := &fakeSubscribe{
+		events: []client.WorkflowEvent{
+			// Delivered live as well, but the client saw it before reconnecting
+			workflowEvent(t, `{"workflowRunId": "`+testRunID+`", "resourceType": "RESOURCE_TYPE_STEP_RUN",
+				"eventType": "RESOURCE_EVENT_TYPE_STARTED", "resourceId": "`+testStepRunID+`",
+				"eventTimestamp": "2025-10-01T12:00:00Z"}`),
+			workflowEvent(t, `{"workflowRunId": "`+testRunID+`", "resourceType": "RESOURCE_TYPE_WORKFLOW_RUN",
+				"eventType": "RESOURCE_EVENT_TYPE_TIMED_OUT", "eventTimestamp": "2025-10-01T12:00:05Z"}`),
+		},
+		hold: true,
+	}
+	e := newStreamServer(t, &fakeHatchet{finishAfter: 1000}, sub, HeartbeatInterval)
+
+	lastEventID := strconv.FormatInt(testStartedAt.UnixMicro(), 10)
+	rec := streamRequest(e, context.Background(), lastEventID)
+	events := readEvents(t, rec.Body.String())
+	require.Len(t, events, 1, "the step start was seen before reconnecting")
+
+	assert.Equal(t, EventRunFinished, events[0].name)
+	assert.Equal(t, rest.V1TaskStatusFAILED, events[0].data.Status, "timed out runs are reported as failed")
+	assert.Equal(t, "run timed out", events[0].data.Message)
+}
+
+func TestStreamRunHeartbeatAndDisconnect(t *testing.T) {
+	e := newStreamServer(t, &fakeHatchet{finishAfter: 1000}, &fakeSubscribe{hold: true}, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	rec := streamRequest(e, ctx, "")
+	assert.Contains(t, rec.Body.String(), ": heartbeat\n\n")
+}
+
+func TestStreamRunFinishedRun(t *testing.T) {
+	hatchet := &fakeHatchet{finishAfter: 0}
+	e := newStreamServer(t, hatchet, &fakeSubscribe{}, HeartbeatInterval)
+
+	rec := streamRequest(e, context.Background(), "")
+	events := readEvents(t, rec.Body.String())
+	require.Len(t, events, 3, "started, completed, finished")
+	assert.Equal(t, "step_completed", events[1].name)
+	assert.Equal(t, EventRunFinished, events[2].name)
+
+	// Reconnecting after run_finished is refused, which stops EventSource retrying
+	rec = streamRequest(e, context.Background(), events[2].id)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
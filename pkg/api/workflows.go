@@ -0,0 +1,115 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/arun0009/hatchetest/pkg/logging"
+	"github.com/hatchet-dev/hatchet/pkg/client"
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StickyKeyMetadata is the metadata key a run's sticky key is recorded under. The SDK cannot pin a run
+// to a worker when triggering it; Hatchet keeps a run's steps together through the workflow's sticky strategy.
+const StickyKeyMetadata = "sticky_key"
+
+// Hatchet's priorities run from low to high
+const (
+	minPriority = 1
+	maxPriority = 3
+)
+
+// TriggerRequest is the body of POST /api/v1/workflows/:name/runs
+type TriggerRequest struct {
+	// Input is the workflow input, a JSON object; it defaults to {}
+	Input json.RawMessage `json:"input,omitempty"`
+	// Metadata is attached to the run as Hatchet additional metadata
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Priority overrides the priority of the run's steps, from 1 (low) to 3 (high)
+	Priority *int32 `json:"priority,omitempty"`
+	// StickyKey is recorded in the run's metadata under StickyKeyMetadata
+	StickyKey string `json:"stickyKey,omitempty"`
+}
+
+// TriggerResponse is the body of a successful trigger
+type TriggerResponse struct {
+	RunID    string `json:"runId"`
+	Workflow string `json:"workflow"`
+}
+
+// triggerRun starts a run of the workflow named in the path and answers 202 with its ID
+func (a *API) triggerRun(c echo.Context) error {
+	workflow := c.Param("name")
+
+	var req TriggerRequest
+	if err := decodeLimited(c, &req, int64(a.current().RunMaxBodyBytes)); err != nil {
+		return bodyFailed(c, err)
+	}
+
+	if len(bytes.TrimSpace(req.Input)) == 0 {
+		req.Input = json.RawMessage("{}")
+	}
+	var input any
+	if err := json.Unmarshal(req.Input, &input); err != nil {
+		return invalid(c, fmt.Sprintf("invalid input: %v", err))
+	}
+
+	var fields []FieldError
+	if _, ok := input.(map[string]any); !ok {
+		fields = append(fields, FieldError{Field: "input", Message: "must be a JSON object"})
+	} else {
+		fields = append(fields, a.schemas.Validate(workflow, input)...)
+	}
+	if req.Priority != nil && (*req.Priority < minPriority || *req.Priority > maxPriority) {
+		fields = append(fields, FieldError{Field: "priority", Message: fmt.Sprintf("must be between %d and %d", minPriority, maxPriority)})
+	}
+	if len(fields) > 0 {
+		return fail(c, http.StatusBadRequest, CodeValidationFailed, "request failed validation", fields...)
+	}
+
+	var opts []client.RunOptFunc
+	metadata := req.Metadata
+	if req.StickyKey != "" {
+		metadata = make(map[string]string, len(req.Metadata)+1)
+		for k, v := range req.Metadata {
+			metadata[k] = v
+		}
+		metadata[StickyKeyMetadata] = req.StickyKey
+	}
+	if len(metadata) > 0 {
+		opts = append(opts, client.WithRunMetadata(metadata))
+	}
+	if req.Priority != nil {
+		opts = append(opts, client.WithPriority(*req.Priority))
+	}
+
+	run, err := a.client.Admin().RunWorkflow(workflow, req.Input, opts...)
+	if err != nil {
+		return a.triggerFailed(c, workflow, err)
+	}
+
+	a.logger.InfoContext(logging.WithRunID(c.Request().Context(), run.RunId()), "workflow run triggered", "workflow", workflow)
+	c.Response().Header().Set(echo.HeaderLocation, "/api/v1/runs/"+run.RunId())
+	return c.JSON(http.StatusAccepted, TriggerResponse{RunID: run.RunId(), Workflow: workflow})
+}
+
+// triggerFailed maps an error from Hatchet to a response
+func (a *API) triggerFailed(c echo.Context, workflow string, err error) error {
+	var dedupe *client.DedupeViolationErr
+	if errors.As(err, &dedupe) {
+		return fail(c, http.StatusConflict, CodeDuplicateRun, err.Error())
+	}
+	switch status.Code(err) {
+	case codes.NotFound:
+		return fail(c, http.StatusNotFound, CodeWorkflowNotFound, fmt.Sprintf("workflow %s not found", workflow))
+	case codes.InvalidArgument:
+		return invalid(c, err.Error())
+	}
+	a.logger.ErrorContext(c.Request().Context(), "failed to trigger workflow", "workflow", workflow, "error", err)
+	return fail(c, http.StatusBadGateway, CodeHatchetError, "failed to trigger workflow run")
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/arun0009/hatchetest/pkg/config"
+	"github.com/hatchet-dev/hatchet/pkg/client"
+	"github.com/hatchet-dev/hatchet/pkg/client/rest"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+const testTenantID = "707d0855-80ab-4e1f-a156-f1c4546cbf52"
+
+// testConfig is the configuration the API is tested under
+func testConfig() *config.AppConfig {
+	return &config.AppConfig{
+		RunMaxBodyBytes:      1024,
+		EventKeys:            []string{"order:*"},
+		EventMaxPayloadBytes: 64,
+		EventMaxBulkEvents:   2,
+	}
+}
+
+// fakeClient implements the parts of client.Client the API uses
+type fakeClient struct {
+	client.Client
+	admin     *fakeAdmin
+	rest      *rest.ClientWithResponses
+	subscribe *fakeSubscribe
+	namespace string
+}
+
+func (f *fakeClient) TenantId() string                  { return testTenantID }
+func (f *fakeClient) Namespace() string                 { return f.namespace }
+func (f *fakeClient) Admin() client.AdminClient         { return f.admin }
+func (f *fakeClient) API() *rest.ClientWithResponses    { return f.rest }
+func (f *fakeClient) Subscribe() client.SubscribeClient { return f.subscribe }
+
+// fakeAdmin records the workflow runs it is asked to trigger
+type fakeAdmin struct {
+	client.AdminClient
+	err error
+
+	workflow string
+	input    any
+	opts     []client.RunOptFunc
+}
+
+func (f *fakeAdmin) RunWorkflow(workflow string, input any, opts ...client.RunOptFunc) (*client.Workflow, error) {
+	f.workflow, f.input, f.opts = workflow, input, opts
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &client.Workflow{}, nil
+}
+
+const orderSchema = `{
+	"type": "object",
+	"required": ["orderId", "amount"],
+	"properties": {
+		"orderId": {"type": "string"},
+		"amount": {"type": "number", "minimum": 0}
+	}
+}`
+
+func newTestServer(t *testing.T, admin *fakeAdmin) *echo.Echo {
+	t.Helper()
+	schemas := NewSchemas()
+	require.NoError(t, schemas.Register("order-workflow", []byte(orderSchema)))
+
+	e := echo.New()
+	New(&fakeClient{admin: admin}, testConfig, schemas, discard).Register(e)
+	return e
+}
+
+func post(e *echo.Echo, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) Error {
+	t.Helper()
+	var body map[string]Error
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body), rec.Body.String())
+	return body["error"]
+}
+
+func TestTriggerRun(t *testing.T) {
+	admin := &fakeAdmin{}
+	e := newTestServer(t, admin)
+
+	rec := post(e, "/api/v1/workflows/order-workflow/runs",
+		`{"input": {"orderId": "o-1", "amount": 12.5}, "metadata": {"source": "checkout"}, "priority": 3, "stickyKey": "customer-7"}`)
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+
+	var resp TriggerResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "order-workflow", resp.Workflow)
+	assert.Equal(t, "order-workflow", admin.workflow)
+	assert.JSONEq(t, `{"orderId": "o-1", "amount": 12.5}`, string(admin.input.(json.RawMessage)))
+	assert.Len(t, admin.opts, 2, "metadata and priority")
+
+	// Workflows without a schema take any object
+	rec = post(e, "/api/v1/workflows/other/runs", `{}`)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.JSONEq(t, `{}`, string(admin.input.(json.RawMessage)))
+	assert.Empty(t, admin.opts)
+}
+
+func TestTriggerRunValidation(t *testing.T) {
+	admin := &fakeAdmin{}
+	e := newTestServer(t, admin)
+
+	rec := post(e, "/api/v1/workflows/order-workflow/runs", `{"input": {"amount": -1}, "priority": 9}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	apiErr := decodeError(t, rec)
+	assert.Equal(t, CodeValidationFailed, apiErr.Code)
+
+	fields := map[string]string{}
+	for _, f := range apiErr.Fields {
+		fields[f.Field] = f.Message
+	}
+	assert.Equal(t, `property "orderId" is missing`, fields["input.orderId"])
+	assert.Contains(t, fields, "input.amount")
+	assert.Contains(t, fields, "priority")
+	assert.Empty(t, admin.workflow, "nothing is triggered")
+
+	rec = post(e, "/api/v1/workflows/other/runs", `{"input": [1, 2]}`)
+	assert.Equal(t, CodeValidationFailed, decodeError(t, rec).Code)
+
+	rec = post(e, "/api/v1/workflows/other/runs", `{"inputs": {}}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, CodeInvalidRequest, decodeError(t, rec).Code)
+
+	rec = post(e, "/api/v1/workflows/other/runs", `{"input": {"note": "`+strings.Repeat("x", 2048)+`"}}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code, "over RunMaxBodyBytes")
+	assert.Equal(t, CodePayloadTooLarge, decodeError(t, rec).Code)
+	assert.Empty(t, admin.workflow, "nothing is triggered")
+}
+
+func TestTriggerRunHatchetErrors(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{&client.DedupeViolationErr{}, http.StatusConflict, CodeDuplicateRun},
+		{status.Error(codes.NotFound, "workflow not found"), http.StatusNotFound, CodeWorkflowNotFound},
+		{errors.New("connection refused"), http.StatusBadGateway, CodeHatchetError},
+	}
+	for _, tt := range tests {
+		e := newTestServer(t, &fakeAdmin{err: tt.err})
+		rec := post(e, "/api/v1/workflows/other/runs", `{}`)
+		assert.Equal(t, tt.status, rec.Code, tt.err.Error())
+		assert.Equal(t, tt.code, decodeError(t, rec).Code)
+	}
+}
+
+func TestSchemasRegisterRejectsInvalidSchema(t *testing.T) {
+	assert.Error(t, NewSchemas().Register("bad", []byte(`{"type": "nonsense"}`)))
+	assert.Error(t, NewSchemas().Register("bad", []byte(`not json`)))
+}
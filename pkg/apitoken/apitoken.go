@@ -0,0 +1,135 @@
+package apitoken
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTenantID is the tenant hatchet-lite seeds on first start
+	DefaultTenantID = "707d0855-80ab-4e1f-a156-f1c4546cbf52"
+
+	// DefaultExpiresIn matches the hatchet-admin default token lifetime
+	DefaultExpiresIn = 90 * 24 * time.Hour
+)
+
+// CreateRequest describes the API token to create
+type CreateRequest struct {
+	// Name labels the token in the Hatchet UI. Defaults to "default".
+	Name string
+	// TenantID is the tenant the token is scoped to. Defaults to DefaultTenantID.
+	TenantID string
+	// ExpiresIn is the token lifetime. Defaults to DefaultExpiresIn.
+	ExpiresIn time.Duration
+}
+
+func (r CreateRequest) withDefaults() CreateRequest {
+	if r.Name == "" {
+		r.Name = "default"
+	}
+	if r.TenantID == "" {
+		r.TenantID = DefaultTenantID
+	}
+	if r.ExpiresIn == 0 {
+		r.ExpiresIn = DefaultExpiresIn
+	}
+	return r
+}
+
+// Token is a created API token together with its decoded claims
+type Token struct {
+	Raw    string `json:"token"`
+	Claims Claims `json:"claims"`
+}
+
+// Claims are the fields Hatchet puts in an API token. They are decoded, not verified.
+type Claims struct {
+	TenantID             string    `json:"tenantId"`
+	TokenID              string    `json:"tokenId,omitempty"`
+	IssuedAt             time.Time `json:"issuedAt"`
+	ExpiresAt            time.Time `json:"expiresAt"`
+	ServerURL            string    `json:"serverUrl,omitempty"`
+	GRPCBroadcastAddress string    `json:"grpcBroadcastAddress,omitempty"`
+}
+
+// TokenProvisioner creates Hatchet API tokens
+type TokenProvisioner interface {
+	Create(ctx context.Context, req CreateRequest) (*Token, error)
+}
+
+// jwtCandidate matches anything shaped like header.payload.signature in base64url
+var jwtCandidate = regexp.MustCompile(`[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+
+// ParseAdminOutput extracts the token from `hatchet-admin token create` output.
+// The command may print log lines around the token, so every JWT-shaped string is
+// tried from the last one backwards and the first that decodes to Hatchet claims wins.
+func ParseAdminOutput(output []byte) (*Token, error) {
+	candidates := jwtCandidate.FindAllString(string(output), -1)
+	for i := len(candidates) - 1; i >= 0; i-- {
+		claims, err := ParseClaims(candidates[i])
+		if err == nil {
+			return &Token{Raw: candidates[i], Claims: claims}, nil
+		}
+	}
+	return nil, fmt.Errorf("no API token found in output: %q", strings.TrimSpace(string(output)))
+}
+
+// ParseClaims decodes the claims of a Hatchet API token without verifying its signature
+func ParseClaims(raw string) (Claims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return Claims{}, fmt.Errorf("token has %d segments, want 3", len(parts))
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Claims{}, fmt.Errorf("invalid token header: %w", err)
+	}
+	if header.Alg == "" {
+		return Claims{}, fmt.Errorf("token header has no alg")
+	}
+
+	var payload struct {
+		Sub                  string `json:"sub"`
+		Iat                  int64  `json:"iat"`
+		Exp                  int64  `json:"exp"`
+		TokenID              string `json:"token_id"`
+		ServerURL            string `json:"server_url"`
+		GRPCBroadcastAddress string `json:"grpc_broadcast_address"`
+	}
+	if err := decodeSegment(parts[1], &payload); err != nil {
+		return Claims{}, fmt.Errorf("invalid token payload: %w", err)
+	}
+	if payload.Sub == "" {
+		return Claims{}, fmt.Errorf("token has no sub (tenant ID) claim")
+	}
+
+	claims := Claims{
+		TenantID:             payload.Sub,
+		TokenID:              payload.TokenID,
+		ServerURL:            payload.ServerURL,
+		GRPCBroadcastAddress: payload.GRPCBroadcastAddress,
+	}
+	if payload.Iat != 0 {
+		claims.IssuedAt = time.Unix(payload.Iat, 0).UTC()
+	}
+	if payload.Exp != 0 {
+		claims.ExpiresAt = time.Unix(payload.Exp, 0).UTC()
+	}
+	return claims, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,44 @@
+package apitoken
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testToken(t *testing.T, payload string) string {
+	t.Helper()
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"ES256","typ":"JWT"}`)) + "." +
+		enc.EncodeToString([]byte(payload)) + "." +
+		enc.EncodeToString([]byte("signature"))
+}
+
+func TestParseAdminOutput(t *testing.T) {
+	raw := testToken(t, `{"sub":"707d0855-80ab-4e1f-a156-f1c4546cbf52","iat":1700000000,"exp":1707776000,"token_id":"abc","server_url":"http://localhost:8888","grpc_broadcast_address":"localhost:7077"}`)
+	output := "2024/01/01 00:00:00 loaded config from /config/server.yaml\n" +
+		"warning: something.looking.likeajwt\n" +
+		raw + "\r\n"
+
+	token, err := ParseAdminOutput([]byte(output))
+	require.NoError(t, err)
+
+	assert.Equal(t, raw, token.Raw)
+	assert.Equal(t, "707d0855-80ab-4e1f-a156-f1c4546cbf52", token.Claims.TenantID)
+	assert.Equal(t, "abc", token.Claims.TokenID)
+	assert.Equal(t, time.Unix(1707776000, 0).UTC(), token.Claims.ExpiresAt)
+	assert.Equal(t, "localhost:7077", token.Claims.GRPCBroadcastAddress)
+}
+
+func TestParseAdminOutputWithoutToken(t *testing.T) {
+	_, err := ParseAdminOutput([]byte("Fatal: could not run [token create] command: tenant not found\n"))
+	assert.ErrorContains(t, err, "no API token found")
+}
+
+func TestParseClaimsRequiresTenant(t *testing.T) {
+	_, err := ParseClaims(testToken(t, `{"exp":1707776000}`))
+	assert.ErrorContains(t, err, "sub")
+}
@@ -0,0 +1,153 @@
+package apitoken
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hatchet-dev/hatchet/pkg/client/rest"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+const (
+	// Credentials of the admin user hatchet-lite seeds on first start
+	DefaultAdminEmail    = "admin@example.com"
+	DefaultAdminPassword = "Admin123!!"
+)
+
+// CommandRunner runs a command and returns its combined output
+type CommandRunner func(ctx context.Context, args []string) ([]byte, error)
+
+// LocalRunner runs commands on the local machine
+func LocalRunner(ctx context.Context, args []string) ([]byte, error) {
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return out, fmt.Errorf("%s: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return out, nil
+}
+
+// AdminCommandProvisioner creates tokens with `hatchet-admin token create`.
+// It needs direct access to the Hatchet server config, so it runs next to the server,
+// e.g. inside the hatchet-lite container.
+type AdminCommandProvisioner struct {
+	// Run executes the command. Defaults to LocalRunner.
+	Run CommandRunner
+	// Binary is the hatchet-admin executable. Defaults to "/hatchet-admin".
+	Binary string
+	// ConfigDir is the Hatchet server config directory. Defaults to "/config".
+	ConfigDir string
+}
+
+// Create implements TokenProvisioner
+func (p *AdminCommandProvisioner) Create(ctx context.Context, req CreateRequest) (*Token, error) {
+	req = req.withDefaults()
+
+	run := p.Run
+	if run == nil {
+		run = LocalRunner
+	}
+	binary := p.Binary
+	if binary == "" {
+		binary = "/hatchet-admin"
+	}
+	configDir := p.ConfigDir
+	if configDir == "" {
+		configDir = "/config"
+	}
+
+	out, err := run(ctx, []string{
+		binary, "token", "create",
+		"--config", configDir,
+		"--tenant-id", req.TenantID,
+		"--name", req.Name,
+		"--expiresIn", req.ExpiresIn.String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to run token create command: %w", err)
+	}
+	return ParseAdminOutput(out)
+}
+
+// RESTProvisioner creates tokens through the Hatchet REST API using a logged-in user session.
+// It works against any reachable Hatchet server, without access to its config.
+type RESTProvisioner struct {
+	Session *Session
+}
+
+// Create implements TokenProvisioner
+func (p *RESTProvisioner) Create(ctx context.Context, req CreateRequest) (*Token, error) {
+	req = req.withDefaults()
+
+	tenantID, err := uuid.Parse(req.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tenant ID %q: %w", req.TenantID, err)
+	}
+	expiresIn := req.ExpiresIn.String()
+
+	resp, err := p.Session.API().ApiTokenCreateWithResponse(ctx, tenantID, rest.CreateAPITokenRequest{
+		Name:      req.Name,
+		ExpiresIn: &expiresIn,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API token: %w", err)
+	}
+	if resp.JSON200 == nil {
+		return nil, fmt.Errorf("failed to create API token: %s: %s", resp.Status(), strings.TrimSpace(string(resp.Body)))
+	}
+
+	claims, err := ParseClaims(resp.JSON200.Token)
+	if err != nil {
+		return nil, fmt.Errorf("server returned an unreadable token: %w", err)
+	}
+	return &Token{Raw: resp.JSON200.Token, Claims: claims}, nil
+}
+
+// Session is a cookie-authenticated session against the Hatchet REST API.
+// Tenant and token management endpoints only accept user sessions, not API tokens.
+type Session struct {
+	api     *rest.ClientWithResponses
+	cookies []*http.Cookie
+}
+
+// Login opens a Session on the Hatchet REST API at serverURL
+func Login(ctx context.Context, serverURL, email, password string) (*Session, error) {
+	session := &Session{}
+
+	api, err := rest.NewClientWithResponses(serverURL, rest.WithRequestEditorFn(session.addCookies))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create REST client: %w", err)
+	}
+	session.api = api
+
+	resp, err := api.UserUpdateLoginWithResponse(ctx, rest.UserLoginRequest{
+		Email:    openapi_types.Email(email),
+		Password: password,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to log in as %s: %w", email, err)
+	}
+	if resp.JSON200 == nil {
+		return nil, fmt.Errorf("failed to log in as %s: %s", email, resp.Status())
+	}
+
+	// The session cookie is carried by hand rather than through a cookie jar, since
+	// Hatchet scopes it to its configured cookie domain, not the host we reached it on
+	session.cookies = resp.HTTPResponse.Cookies()
+	return session, nil
+}
+
+// API returns the REST client authenticated as the session user
+func (s *Session) API() *rest.ClientWithResponses {
+	return s.api
+}
+
+func (s *Session) addCookies(ctx context.Context, req *http.Request) error {
+	for _, cookie := range s.cookies {
+		req.AddCookie(cookie)
+	}
+	return nil
+}
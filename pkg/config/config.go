@@ -1,30 +1,82 @@
 package config
 
 import (
-	"github.com/caarlos0/env/v11"
+	"os"
+	"time"
 )
 
-// AppConfig holds the global application configuration
+// AppConfig holds the global application configuration.
+//
+// Each field can be set from four layers, later ones winning:
+// its envDefault (or the profile's default), the config file key in its file tag,
+// the env var in its env tag, and the command line flag in its flag tag.
+// Fields tagged reload:"hot" can change while the app runs; see Watcher. Fields with a redact tag hold
+// secrets and are never printed in full; see Effective.
 type AppConfig struct {
+	// AppEnv is the profile the rest of the config was resolved under
+	AppEnv Profile `env:"APP_ENV" envDefault:"dev" file:"appEnv" flag:"app-env"`
+
 	// Server configuration
-	Port     int    `env:"PORT" envDefault:"8080"`
-	Host     string `env:"HOST" envDefault:"localhost"`
-	LogLevel string `env:"LOG_LEVEL" envDefault:"info"`
+	Port      int    `env:"PORT" envDefault:"8080" file:"port" flag:"port"`
+	Host      string `env:"HOST" envDefault:"localhost" file:"host" flag:"host"`
+	LogLevel  string `env:"LOG_LEVEL" envDefault:"info" file:"logLevel" flag:"log-level" reload:"hot"`
+	LogFormat string `env:"LOG_FORMAT" envDefault:"text" file:"logFormat" flag:"log-format"`
+	// ShutdownTimeout bounds how long a SIGINT or SIGTERM waits for HTTP requests and running steps
+	ShutdownTimeout  time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"30s" file:"shutdownTimeout" flag:"shutdown-timeout" reload:"hot"`
+	CORSAllowOrigins []string      `env:"CORS_ALLOW_ORIGINS" envDefault:"*" envSeparator:"," file:"corsAllowOrigins" flag:"cors-allow-origins"`
 
 	// Hatchet configuration
-	HatchetServerURL string `env:"HATCHET_CLIENT_SERVER_URL" envDefault:"http://localhost:8888"`
-	HatchetHostPort  string `env:"HATCHET_CLIENT_HOST_PORT" envDefault:"localhost:7070"`
-	HatchetToken     string `env:"HATCHET_CLIENT_TOKEN" envDefault:"test-token-for-integration"`
+	HatchetServerURL string `env:"HATCHET_CLIENT_SERVER_URL" envDefault:"http://localhost:8888" file:"serverUrl" flag:"hatchet-server-url"`
+	HatchetHostPort  string `env:"HATCHET_CLIENT_HOST_PORT" envDefault:"localhost:7070" file:"hostPort" flag:"hatchet-host-port"`
+	HatchetToken     string `env:"HATCHET_CLIENT_TOKEN" envDefault:"test-token-for-integration" file:"token" flag:"hatchet-token" redact:"true"`
+	HatchetTokenFile string `env:"HATCHET_CLIENT_TOKEN_FILE" file:"tokenFile" flag:"hatchet-token-file"`
+
+	// Hatchet worker supervision: an attempt that fails, or is not active within WorkerStartTimeout,
+	// is retried up to WorkerMaxRetries times with backoff doubling from WorkerRetryBackoff to
+	// WorkerRetryMaxBackoff; after that the process exits. WorkerMaxRetries 0 exits on the first failure.
+	WorkerStartTimeout    time.Duration `env:"WORKER_START_TIMEOUT" envDefault:"30s" file:"worker.startTimeout" flag:"worker-start-timeout"`
+	WorkerMaxRetries      int           `env:"WORKER_MAX_RETRIES" envDefault:"5" file:"worker.maxRetries" flag:"worker-max-retries"`
+	WorkerRetryBackoff    time.Duration `env:"WORKER_RETRY_BACKOFF" envDefault:"1s" file:"worker.retryBackoff" flag:"worker-retry-backoff"`
+	WorkerRetryMaxBackoff time.Duration `env:"WORKER_RETRY_MAX_BACKOFF" envDefault:"30s" file:"worker.retryMaxBackoff" flag:"worker-retry-max-backoff"`
+
+	// Hatchet gRPC TLS: strategy is none, tls or mtls. The CA defaults to the system pool,
+	// the client cert and key are only used for mtls, and the server name defaults to the host of HatchetHostPort.
+	HatchetTLSStrategy   string `env:"HATCHET_CLIENT_TLS_STRATEGY" envDefault:"tls" file:"tlsConfig.tlsStrategy" flag:"hatchet-tls-strategy"`
+	HatchetTLSRootCAFile string `env:"HATCHET_CLIENT_TLS_ROOT_CA_FILE" file:"tlsConfig.tlsRootCAFile" flag:"hatchet-tls-root-ca-file"`
+	HatchetTLSCertFile   string `env:"HATCHET_CLIENT_TLS_CERT_FILE" file:"tlsConfig.tlsCertFile" flag:"hatchet-tls-cert-file"`
+	HatchetTLSKeyFile    string `env:"HATCHET_CLIENT_TLS_KEY_FILE" file:"tlsConfig.tlsKeyFile" flag:"hatchet-tls-key-file"`
+	HatchetTLSServerName string `env:"HATCHET_CLIENT_TLS_SERVER_NAME" file:"tlsConfig.tlsServerName" flag:"hatchet-tls-server-name"`
+
+	// AdminToken is the bearer token for the /admin endpoints; they are disabled while it is empty
+	AdminToken string `env:"ADMIN_TOKEN" file:"adminToken" flag:"admin-token" reload:"hot" redact:"true"`
+
+	// HTTP API: WorkflowSchemaDir holds a JSON schema for each workflow's input, named <workflow>.json;
+	// workflows without one accept any input
+	WorkflowSchemaDir string `env:"WORKFLOW_SCHEMA_DIR" file:"api.workflowSchemaDir" flag:"workflow-schema-dir"`
+	// RunMaxBodyBytes caps the body of a request to trigger a workflow run
+	RunMaxBodyBytes int `env:"RUN_MAX_BODY_BYTES" envDefault:"1048576" file:"api.runMaxBodyBytes" flag:"run-max-body-bytes" reload:"hot"`
+	// EventKeys lists the event keys the event push API accepts, as path.Match patterns such as "order:*";
+	// while it is empty every key is refused. EventMaxPayloadBytes caps each event's payload and
+	// EventMaxBulkEvents the events in one bulk push.
+	EventKeys            []string `env:"EVENT_KEYS" envSeparator:"," file:"api.eventKeys" flag:"event-keys" reload:"hot"`
+	EventMaxPayloadBytes int      `env:"EVENT_MAX_PAYLOAD_BYTES" envDefault:"65536" file:"api.eventMaxPayloadBytes" flag:"event-max-payload-bytes" reload:"hot"`
+	EventMaxBulkEvents   int      `env:"EVENT_MAX_BULK_EVENTS" envDefault:"100" file:"api.eventMaxBulkEvents" flag:"event-max-bulk-events" reload:"hot"`
 
 	// Database configuration (for future use)
-	DatabaseURL string `env:"DATABASE_URL" envDefault:""`
+	DatabaseURL string `env:"DATABASE_URL" envDefault:"" file:"databaseUrl" flag:"database-url" redact:"password"`
+
+	// sources records which layer set each field, keyed by field name
+	sources map[string]Source
+	// configFile is the config file Load looked for, whether or not it existed
+	configFile string
 }
 
-// LoadAppConfig loads the global application configuration from environment variables
+// LoadAppConfig loads the global application configuration from the config file, if any, and environment variables
 func LoadAppConfig() (*AppConfig, error) {
-	cfg := &AppConfig{}
-	if err := env.Parse(cfg); err != nil {
-		return nil, err
-	}
-	return cfg, nil
+	return Load(WithEnviron(os.Environ()))
+}
+
+// ConfigFile returns the path of the config file Load read, or would have read had it existed
+func (c *AppConfig) ConfigFile() string {
+	return c.configFile
 }
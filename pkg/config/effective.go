@@ -0,0 +1,89 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Setting is one field of the effective configuration
+type Setting struct {
+	Field  string `json:"field"`
+	Env    string `json:"env"`
+	Value  any    `json:"value"`
+	Source Source `json:"source,omitempty"`
+	// Redacted is set when Value has a secret hidden
+	Redacted bool `json:"redacted,omitempty"`
+}
+
+// Effective is the configuration a process runs with, safe to print or serve
+type Effective struct {
+	Profile    Profile   `json:"profile"`
+	ConfigFile string    `json:"configFile,omitempty"`
+	Settings   []Setting `json:"settings"`
+}
+
+// Effective returns every field with its source, in declaration order.
+// Secrets are replaced with [redacted]; DatabaseURL keeps everything but its password.
+func (c *AppConfig) Effective() Effective {
+	v := reflect.ValueOf(c).Elem()
+	out := Effective{Profile: c.AppEnv, ConfigFile: c.configFile}
+	for _, f := range fields() {
+		value, hidden := redactValue(f.redact, v.Field(f.index).Interface())
+		out.Settings = append(out.Settings, Setting{
+			Field:    f.name,
+			Env:      f.env,
+			Value:    value,
+			Source:   c.Source(f.name),
+			Redacted: hidden,
+		})
+	}
+	return out
+}
+
+// redactValue hides value according to a field's redact tag and reports whether anything was hidden.
+// Durations are shown as strings, as they are written in config.
+func redactValue(mode string, value any) (any, bool) {
+	if d, ok := value.(time.Duration); ok {
+		return d.String(), false
+	}
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return value, false
+	}
+	switch mode {
+	case "":
+		return value, false
+	case "password":
+		r := redactPassword(s)
+		return r, r != s
+	default:
+		return redacted, true
+	}
+}
+
+// redactedPassword is what url.URL.Redacted puts in place of a password
+const redactedPassword = "xxxxx"
+
+// dsnPassword matches the password in a key=value postgres connection string
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// redactPassword hides the password of a postgres URL or key=value connection string
+// the way url.URL.Redacted does
+func redactPassword(dsn string) string {
+	if !strings.Contains(dsn, "://") {
+		return dsnPassword.ReplaceAllString(dsn, "${1}"+redactedPassword)
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		// Unparseable URLs cannot be redacted piecemeal
+		return redacted
+	}
+	if q := u.Query(); q.Has("password") {
+		q.Set("password", redactedPassword)
+		u.RawQuery = q.Encode()
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setting(t *testing.T, e Effective, field string) Setting {
+	t.Helper()
+	for _, s := range e.Settings {
+		if s.Field == field {
+			return s
+		}
+	}
+	t.Fatalf("no setting for %s", field)
+	return Setting{}
+}
+
+func TestEffectiveRedactsSecrets(t *testing.T) {
+	cfg, err := Load(WithEnviron([]string{
+		"HATCHET_CLIENT_TOKEN=" + testToken,
+		"DATABASE_URL=postgres://hatchet:secret@db:5432/hatchet?sslmode=disable",
+	}), WithArgs([]string{"--port", "9000"}))
+	require.NoError(t, err)
+
+	e := cfg.Effective()
+	assert.Equal(t, ProfileDev, e.Profile)
+	assert.Equal(t, Setting{Field: "Port", Env: "PORT", Value: 9000, Source: SourceFlag}, setting(t, e, "Port"))
+	assert.Equal(t, Setting{Field: "HatchetToken", Env: "HATCHET_CLIENT_TOKEN", Value: "[redacted]", Source: SourceEnv, Redacted: true},
+		setting(t, e, "HatchetToken"))
+	assert.Equal(t, "postgres://hatchet:xxxxx@db:5432/hatchet?sslmode=disable", setting(t, e, "DatabaseURL").Value)
+	assert.Equal(t, "", setting(t, e, "AdminToken").Value, "empty secrets show as unset")
+}
+
+func TestRedactPassword(t *testing.T) {
+	for dsn, want := range map[string]string{
+		"postgres://db/hatchet?password=secret&user=hatchet": "postgres://db/hatchet?password=xxxxx&user=hatchet",
+		"host=db user=hatchet password=secret":               "host=db user=hatchet password=xxxxx",
+		"host=db password='se cret' dbname=hatchet":          "host=db password=xxxxx dbname=hatchet",
+		"postgres://hatchet@db/hatchet":                      "postgres://hatchet@db/hatchet",
+	} {
+		assert.Equal(t, want, redactPassword(dsn), dsn)
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/hatchet-dev/hatchet/pkg/client"
+	clientconfig "github.com/hatchet-dev/hatchet/pkg/config/client"
+	"github.com/hatchet-dev/hatchet/pkg/config/shared"
+)
+
+// ClientConfigFile returns the Hatchet SDK client config for this configuration
+func (c *AppConfig) ClientConfigFile() *clientconfig.ClientConfigFile {
+	return &clientconfig.ClientConfigFile{
+		Token:     c.HatchetToken,
+		HostPort:  c.HatchetHostPort,
+		ServerURL: c.HatchetServerURL,
+		TLS: clientconfig.ClientTLSConfigFile{
+			Base: shared.TLSConfigFile{
+				TLSStrategy:   c.HatchetTLSStrategy,
+				TLSRootCAFile: c.HatchetTLSRootCAFile,
+				TLSCertFile:   c.HatchetTLSCertFile,
+				TLSKeyFile:    c.HatchetTLSKeyFile,
+			},
+			TLSServerName: c.tlsServerName(),
+		},
+	}
+}
+
+// tlsServerName returns the name the Hatchet server certificate is checked against.
+// The SDK only derives it from the host for the tls strategy, so it is filled in here for mtls too.
+func (c *AppConfig) tlsServerName() string {
+	if c.HatchetTLSServerName != "" {
+		return c.HatchetTLSServerName
+	}
+	host, _, err := net.SplitHostPort(c.HatchetHostPort)
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+// NewHatchetClient creates a Hatchet client from this configuration without going through the process environment.
+// The SDK still lets HATCHET_CLIENT_* variables override its config file, so the token and host:port are also
+// passed as options, keeping a flag ahead of the environment.
+func (c *AppConfig) NewHatchetClient(opts ...client.ClientOpt) (hatchetClient client.Client, err error) {
+	host, portStr, err := net.SplitHostPort(c.HatchetHostPort)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Hatchet host:port %q: %w", c.HatchetHostPort, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Hatchet port %q: %w", portStr, err)
+	}
+
+	// The SDK panics rather than returning an error when its config is incomplete
+	defer func() {
+		if r := recover(); r != nil {
+			hatchetClient, err = nil, fmt.Errorf("invalid Hatchet client config: %v", r)
+		}
+	}()
+
+	return client.NewFromConfigFile(c.ClientConfigFile(), append([]client.ClientOpt{
+		client.WithToken(c.HatchetToken),
+		client.WithHostPort(host, port),
+	}, opts...)...)
+}
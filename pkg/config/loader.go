@@ -0,0 +1,326 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/caarlos0/env/v11"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultConfigFile is read when it exists and no other file is given
+	DefaultConfigFile = ".hatchet/config.yaml"
+
+	// ConfigFileEnv names an alternative config file. The --config flag takes precedence over it.
+	ConfigFileEnv = "HATCHETEST_CONFIG_FILE"
+)
+
+// LoadOption configures Load
+type LoadOption func(*loader)
+
+// WithFile reads the config file at path instead of DefaultConfigFile. Unlike the default, it must exist.
+func WithFile(path string) LoadOption {
+	return func(l *loader) {
+		l.file = path
+	}
+}
+
+// WithEnviron sets the environment, as KEY=value pairs like os.Environ returns
+func WithEnviron(environ []string) LoadOption {
+	return func(l *loader) {
+		l.environ = environ
+	}
+}
+
+// WithArgs sets the command line flags, e.g. os.Args[1:]
+func WithArgs(args []string) LoadOption {
+	return func(l *loader) {
+		l.args = args
+	}
+}
+
+// WithFlagOutput sets where flag parse errors and usage are written. Defaults to stderr.
+func WithFlagOutput(w io.Writer) LoadOption {
+	return func(l *loader) {
+		l.flagOutput = w
+	}
+}
+
+type loader struct {
+	file          string
+	environ       []string
+	args          []string
+	flagOutput    io.Writer
+	tokenProvider SecretProvider
+}
+
+// field describes where one AppConfig field can be set from
+type field struct {
+	index int
+	name  string
+	env   string
+	file  string
+	flag  string
+	hot   bool
+	// redact is "true" to hide the whole value, or "password" to hide only the password in a DSN
+	redact string
+}
+
+// fields lists the settable AppConfig fields from their struct tags
+func fields() []field {
+	t := reflect.TypeOf(AppConfig{})
+	out := make([]field, 0, t.NumField())
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		key, ok := sf.Tag.Lookup("env")
+		if !ok {
+			continue
+		}
+		out = append(out, field{
+			index:  i,
+			name:   sf.Name,
+			env:    key,
+			file:   sf.Tag.Get("file"),
+			flag:   sf.Tag.Get("flag"),
+			hot:    sf.Tag.Get("reload") == "hot",
+			redact: sf.Tag.Get("redact"),
+		})
+	}
+	return out
+}
+
+// Load builds an AppConfig from, in increasing precedence: defaults, a YAML or JSON config file,
+// environment variables and command line flags. Only the layers given as options are read,
+// apart from defaults and DefaultConfigFile. HatchetToken is then replaced by the secret from
+// WithSecretProvider or HatchetTokenFile, if either is set.
+func Load(opts ...LoadOption) (*AppConfig, error) {
+	l := &loader{flagOutput: os.Stderr}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	envValues := environMap(l.environ)
+	flagValues, flagFile, err := l.parseFlags()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &AppConfig{}
+	if err := env.ParseWithOptions(cfg, env.Options{Environment: map[string]string{}}); err != nil {
+		return nil, fmt.Errorf("invalid config defaults: %w", err)
+	}
+	for _, f := range fields() {
+		cfg.setSource(f.name, SourceDefault)
+	}
+
+	path, required := DefaultConfigFile, false
+	for _, p := range []string{l.file, envValues[ConfigFileEnv], flagFile} {
+		if p != "" {
+			path, required = p, true
+		}
+	}
+	fileValues, err := readFile(path, required)
+	if err != nil {
+		return nil, err
+	}
+	cfg.configFile = path
+
+	layers := []struct {
+		name   string
+		source Source
+		values map[string]string
+	}{
+		{name: "config file " + path, source: SourceFile, values: fileValues},
+		{name: "environment", source: SourceEnv, values: envValues},
+		{name: "flags", source: SourceFlag, values: flagValues},
+	}
+	for _, layer := range layers {
+		if err := apply(cfg, layer.values, layer.source, false); err != nil {
+			return nil, fmt.Errorf("invalid value in %s: %w", layer.name, err)
+		}
+	}
+
+	// Profile defaults can only be picked once the profile is known, and only replace plain defaults
+	defaults := map[string]string{}
+	for _, f := range fields() {
+		if v, ok := profileDefaults[cfg.AppEnv][f.env]; ok && cfg.Source(f.name) == SourceDefault {
+			defaults[f.env] = v
+		}
+	}
+	if err := apply(cfg, defaults, SourceProfile, true); err != nil {
+		return nil, fmt.Errorf("invalid default in profile %s: %w", cfg.AppEnv, err)
+	}
+
+	// A token from a secret provider or file wins over one given inline
+	provider := l.tokenProvider
+	if provider == nil && cfg.HatchetTokenFile != "" {
+		provider = cfg.TokenProvider()
+	}
+	if provider != nil {
+		token, err := provider.Secret(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Hatchet token: %w", err)
+		}
+		cfg.HatchetToken = token
+		cfg.setSource("HatchetToken", SourceSecret)
+	}
+	return cfg, nil
+}
+
+// apply parses values, keyed by env var name, and copies the fields they set onto cfg, recording source.
+// Empty values are skipped, unless keepEmpty is set, in which case they reset the field to its zero value.
+func apply(cfg *AppConfig, values map[string]string, source Source, keepEmpty bool) error {
+	set := make(map[string]string, len(values))
+	for k, v := range values {
+		if v != "" {
+			set[k] = v
+		}
+	}
+
+	parsed := &AppConfig{}
+	if len(set) > 0 {
+		if err := env.ParseWithOptions(parsed, env.Options{Environment: set}); err != nil {
+			return err
+		}
+	}
+
+	dst, src := reflect.ValueOf(cfg).Elem(), reflect.ValueOf(parsed).Elem()
+	for _, f := range fields() {
+		v, ok := values[f.env]
+		switch {
+		case ok && v != "":
+			dst.Field(f.index).Set(src.Field(f.index))
+		case ok && keepEmpty:
+			dst.Field(f.index).SetZero()
+		default:
+			continue
+		}
+		cfg.setSource(f.name, source)
+	}
+	return nil
+}
+
+// parseFlags returns the values of the flags that were given, keyed by env var name,
+// and the --config path
+func (l *loader) parseFlags() (map[string]string, string, error) {
+	values := map[string]string{}
+	if len(l.args) == 0 {
+		return values, "", nil
+	}
+
+	fs := flag.NewFlagSet("hatchetest", flag.ContinueOnError)
+	fs.SetOutput(l.flagOutput)
+	configFile := fs.String("config", "", fmt.Sprintf("config file (default %s, or $%s)", DefaultConfigFile, ConfigFileEnv))
+
+	byFlag := map[string]field{}
+	for _, f := range fields() {
+		if f.flag == "" {
+			continue
+		}
+		byFlag[f.flag] = f
+		fs.String(f.flag, "", "overrides $"+f.env)
+	}
+	if err := fs.Parse(l.args); err != nil {
+		return nil, "", err
+	}
+	if fs.NArg() > 0 {
+		return nil, "", fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	fs.Visit(func(fl *flag.Flag) {
+		if f, ok := byFlag[fl.Name]; ok {
+			values[f.env] = fl.Value.String()
+		}
+	})
+	return values, *configFile, nil
+}
+
+// readFile reads a YAML or JSON config file and returns its values keyed by env var name.
+// A missing file is only an error when required.
+func readFile(path string, required bool) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// YAML is a superset of JSON, so one decoder covers both
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	flat := map[string]any{}
+	flatten("", doc, flat)
+
+	values := map[string]string{}
+	for _, f := range fields() {
+		if f.file == "" {
+			continue
+		}
+		v, ok := flat[strings.ToLower(f.file)]
+		if !ok || v == nil {
+			continue
+		}
+		s, err := scalarString(v)
+		if err != nil {
+			return nil, fmt.Errorf("config file %s: %s: %w", path, f.file, err)
+		}
+		values[f.env] = s
+	}
+	return values, nil
+}
+
+// flatten turns nested maps into dotted, lower-cased keys, matching keys case-insensitively
+// the way the Hatchet SDK reads its own config file
+func flatten(prefix string, in map[string]any, out map[string]any) {
+	for k, v := range in {
+		key := strings.ToLower(k)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := v.(map[string]any); ok {
+			flatten(key, nested, out)
+			continue
+		}
+		out[key] = v
+	}
+}
+
+func scalarString(v any) (string, error) {
+	switch v := v.(type) {
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			s, err := scalarString(item)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = s
+		}
+		return strings.Join(parts, ","), nil
+	case map[string]any:
+		return "", fmt.Errorf("expected a value, got a map")
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+func environMap(environ []string) map[string]string {
+	m := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			m[k] = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+serverUrl: http://file:8888
+hostPort: file:7077
+token: file-token
+port: 9000
+tlsConfig:
+  tlsStrategy: none
+`)
+
+	cfg, err := Load(
+		WithFile(path),
+		WithEnviron([]string{"HATCHET_CLIENT_HOST_PORT=env:7077", "HATCHET_CLIENT_TOKEN=env-token"}),
+		WithArgs([]string{"--hatchet-token", "flag-token"}),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "localhost", cfg.Host, "default")
+	assert.Equal(t, 9000, cfg.Port, "file over default")
+	assert.Equal(t, "http://file:8888", cfg.HatchetServerURL, "file over default")
+	assert.Equal(t, "none", cfg.HatchetTLSStrategy, "nested file key")
+	assert.Equal(t, "env:7077", cfg.HatchetHostPort, "env over file")
+	assert.Equal(t, "flag-token", cfg.HatchetToken, "flag over env")
+}
+
+func TestLoadJSONFileFromEnv(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `{"port": 9100, "logLevel": "debug"}`)
+
+	cfg, err := Load(WithEnviron([]string{ConfigFileEnv + "=" + path}))
+	require.NoError(t, err)
+	assert.Equal(t, 9100, cfg.Port)
+	assert.Equal(t, "debug", cfg.LogLevel)
+}
+
+func TestLoadErrors(t *testing.T) {
+	_, err := Load(WithFile(filepath.Join(t.TempDir(), "missing.yaml")))
+	assert.Error(t, err, "an explicit config file must exist")
+
+	_, err = Load(WithEnviron([]string{"PORT=eighty"}))
+	assert.ErrorContains(t, err, "environment")
+
+	_, err = Load(WithArgs([]string{"--no-such-flag"}), WithFlagOutput(io.Discard))
+	assert.Error(t, err)
+}
@@ -0,0 +1,71 @@
+package config
+
+// Profile selects per-environment defaults and how strictly the configuration is validated.
+// It is set with APP_ENV, the appEnv config file key or --app-env.
+type Profile string
+
+const (
+	// ProfileDev is for running locally against a plaintext Hatchet stack
+	ProfileDev Profile = "dev"
+	// ProfileTest is for integration tests; it also accepts the placeholder token
+	ProfileTest Profile = "test"
+	// ProfileProd requires TLS to Hatchet, a real token and an explicit CORS allowlist
+	ProfileProd Profile = "prod"
+)
+
+// Profiles are the accepted values of AppEnv
+var Profiles = []string{string(ProfileDev), string(ProfileTest), string(ProfileProd)}
+
+// profileDefaults replace a field's envDefault under a profile, keyed by env var name.
+// An empty value clears the default.
+var profileDefaults = map[Profile]map[string]string{
+	ProfileDev: {
+		"LOG_LEVEL":                   "debug",
+		"HATCHET_CLIENT_TLS_STRATEGY": "none",
+	},
+	ProfileTest: {
+		"LOG_LEVEL":                   "debug",
+		"HATCHET_CLIENT_TLS_STRATEGY": "none",
+	},
+	ProfileProd: {
+		"HOST":                 "0.0.0.0",
+		"LOG_FORMAT":           "json",
+		"HATCHET_CLIENT_TOKEN": "",
+		"CORS_ALLOW_ORIGINS":   "",
+	},
+}
+
+// Source is the layer a configuration value came from
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceProfile Source = "profile"
+	SourceFile    Source = "file"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+	// SourceSecret marks a token read from a SecretProvider or HatchetTokenFile
+	SourceSecret Source = "secret"
+)
+
+// Source returns where a field's value came from, by AppConfig field name, e.g. Source("Port").
+// It is empty for configs that were not built by Load.
+func (c *AppConfig) Source(field string) Source {
+	return c.sources[field]
+}
+
+// Sources returns where every field's value came from, keyed by AppConfig field name
+func (c *AppConfig) Sources() map[string]Source {
+	out := make(map[string]Source, len(c.sources))
+	for k, v := range c.sources {
+		out[k] = v
+	}
+	return out
+}
+
+func (c *AppConfig) setSource(field string, src Source) {
+	if c.sources == nil {
+		c.sources = map[string]Source{}
+	}
+	c.sources[field] = src
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadProfileDefaultsAndSources(t *testing.T) {
+	cfg, err := Load(WithEnviron([]string{"APP_ENV=prod"}), WithArgs([]string{"--port", "9000"}))
+	require.NoError(t, err)
+
+	assert.Equal(t, ProfileProd, cfg.AppEnv)
+	assert.Equal(t, SourceEnv, cfg.Source("AppEnv"))
+	assert.Equal(t, "0.0.0.0", cfg.Host)
+	assert.Equal(t, SourceProfile, cfg.Source("Host"))
+	assert.Empty(t, cfg.HatchetToken, "prod has no placeholder token")
+	assert.Empty(t, cfg.CORSAllowOrigins)
+	assert.Equal(t, "json", cfg.LogFormat)
+	assert.Equal(t, "tls", cfg.HatchetTLSStrategy)
+	assert.Equal(t, SourceDefault, cfg.Source("HatchetTLSStrategy"))
+	assert.Equal(t, SourceFlag, cfg.Source("Port"))
+}
+
+func TestLoadProfileDefaultsYieldToExplicitValues(t *testing.T) {
+	cfg, err := Load(WithEnviron([]string{"APP_ENV=test", "LOG_LEVEL=warn"}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "warn", cfg.LogLevel)
+	assert.Equal(t, SourceEnv, cfg.Source("LogLevel"))
+	assert.Equal(t, "none", cfg.HatchetTLSStrategy)
+	assert.Equal(t, SourceProfile, cfg.Source("HatchetTLSStrategy"))
+	assert.Equal(t, PlaceholderToken, cfg.HatchetToken)
+	assert.NoError(t, cfg.Validate(), "test accepts the placeholder token")
+}
+
+func TestValidateProdStrictness(t *testing.T) {
+	cfg := validConfig()
+	cfg.AppEnv = ProfileProd
+	cfg.CORSAllowOrigins = []string{"https://app.example.com", "*"}
+
+	err := cfg.Validate()
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+
+	var fields []string
+	for _, fe := range verr.Errors {
+		fields = append(fields, fe.Field)
+	}
+	assert.Equal(t, []string{"CORSAllowOrigins", "HatchetTLSStrategy"}, fields)
+}
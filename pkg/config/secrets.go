@@ -0,0 +1,92 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// PlaceholderToken is the HatchetToken default. It only works against test stacks that
+// never check it, so Validate rejects it.
+const PlaceholderToken = "test-token-for-integration"
+
+// SecretProvider supplies a secret from wherever it is kept, e.g. a mounted file or a secret store
+type SecretProvider interface {
+	Secret(ctx context.Context) (string, error)
+}
+
+// StaticSecret is a secret known up front
+type StaticSecret string
+
+// Secret implements SecretProvider
+func (s StaticSecret) Secret(ctx context.Context) (string, error) {
+	return string(s), nil
+}
+
+// FileSecret reads a secret from a file, as mounted by Docker secrets or Kubernetes.
+// Surrounding whitespace, such as a trailing newline, is trimmed.
+type FileSecret struct {
+	Path string
+}
+
+// Secret implements SecretProvider
+func (f FileSecret) Secret(ctx context.Context) (string, error) {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("secret file %s is empty", f.Path)
+	}
+	return secret, nil
+}
+
+// WithSecretProvider sources HatchetToken from p, ahead of every other layer
+func WithSecretProvider(p SecretProvider) LoadOption {
+	return func(l *loader) {
+		l.tokenProvider = p
+	}
+}
+
+// TokenProvider returns where HatchetToken comes from: HatchetTokenFile when set, otherwise the loaded value
+func (c *AppConfig) TokenProvider() SecretProvider {
+	if c.HatchetTokenFile != "" {
+		return FileSecret{Path: c.HatchetTokenFile}
+	}
+	return StaticSecret(c.HatchetToken)
+}
+
+// WatchSecret re-reads p straight away and then every interval until ctx is done, calling onChange with
+// each value that differs from the one before, starting from current, the value in use. Kubernetes rotates
+// mounted secrets by swapping a symlink, so polling the content is more reliable than watching the file.
+// Read errors are passed to onError, if set, and the last value is kept.
+func WatchSecret(ctx context.Context, p SecretProvider, current string, interval time.Duration, onChange func(string), onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := current
+	for first := true; ; first = false {
+		if !first {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+
+		secret, err := p.Secret(ctx)
+		if err != nil {
+			if onError != nil {
+				onError(err)
+			}
+			continue
+		}
+		if secret != last {
+			last = secret
+			onChange(secret)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTokenFile(t *testing.T) {
+	path := writeConfigFile(t, "token", testToken+"\n")
+
+	cfg, err := Load(WithEnviron([]string{
+		"HATCHET_CLIENT_TOKEN=inline-token",
+		"HATCHET_CLIENT_TOKEN_FILE=" + path,
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, testToken, cfg.HatchetToken, "token file wins over inline token")
+
+	_, err = Load(WithEnviron([]string{"HATCHET_CLIENT_TOKEN_FILE=" + path + ".missing"}))
+	assert.Error(t, err)
+}
+
+func TestLoadSecretProvider(t *testing.T) {
+	cfg, err := Load(WithSecretProvider(StaticSecret("from-store")))
+	require.NoError(t, err)
+	assert.Equal(t, "from-store", cfg.HatchetToken)
+}
+
+func TestValidateRejectsPlaceholderToken(t *testing.T) {
+	cfg := validConfig()
+	cfg.HatchetToken = PlaceholderToken
+	assert.ErrorContains(t, cfg.Validate(), "placeholder")
+}
+
+// notifyingSecret closes read after its first read
+type notifyingSecret struct {
+	SecretProvider
+	read chan struct{}
+	once sync.Once
+}
+
+func (n *notifyingSecret) Secret(ctx context.Context) (string, error) {
+	defer n.once.Do(func() { close(n.read) })
+	return n.SecretProvider.Secret(ctx)
+}
+
+func TestWatchSecretSeesRotation(t *testing.T) {
+	path := writeConfigFile(t, "token", "first")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider := &notifyingSecret{SecretProvider: FileSecret{Path: path}, read: make(chan struct{})}
+	changes := make(chan string, 1)
+	go WatchSecret(ctx, provider, "first", 10*time.Millisecond, func(s string) { changes <- s }, nil)
+
+	<-provider.read
+	require.NoError(t, os.WriteFile(path, []byte("second\n"), 0o600))
+
+	select {
+	case got := <-changes:
+		assert.Equal(t, "second", got)
+	case <-time.After(2 * time.Second):
+		t.Fatal("rotation was not noticed")
+	}
+}
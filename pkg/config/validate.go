@@ -0,0 +1,298 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arun0009/hatchetest/pkg/apitoken"
+	"github.com/arun0009/hatchetest/pkg/logging"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// LogLevels are the accepted values of LogLevel
+var LogLevels = []string{"debug", "info", "warn", "error"}
+
+// TLSStrategies are the accepted values of HatchetTLSStrategy
+var TLSStrategies = []string{"none", "tls", "mtls"}
+
+// FieldError is a problem with one AppConfig field
+type FieldError struct {
+	// Field is the AppConfig field name, e.g. "HatchetHostPort"
+	Field string
+	// Env is the env var that sets the field, e.g. "HATCHET_CLIENT_HOST_PORT"
+	Env string
+	Err error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Env, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError holds every problem Validate found
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Error()
+	}
+	return fmt.Sprintf("invalid configuration (%d problems): %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, fe := range e.Errors {
+		errs[i] = fe
+	}
+	return errs
+}
+
+// Validate checks every field and returns a *ValidationError listing all problems, or nil.
+// How strict it is depends on AppEnv: prod requires TLS to Hatchet and an explicit CORS allowlist,
+// and only test accepts the placeholder token.
+func (c *AppConfig) Validate() error {
+	v := &validator{envs: map[string]string{}}
+	for _, f := range fields() {
+		v.envs[f.name] = f.env
+	}
+
+	v.check("AppEnv", validateOneOf(string(c.AppEnv), Profiles))
+	v.check("Port", validatePort(c.Port))
+	v.check("Host", validateNotEmpty(c.Host))
+	v.check("LogLevel", validateOneOf(c.LogLevel, LogLevels))
+	v.check("LogFormat", validateOneOf(c.LogFormat, logging.Formats))
+	v.check("ShutdownTimeout", validatePositive(c.ShutdownTimeout))
+	v.check("WorkerStartTimeout", validatePositive(c.WorkerStartTimeout))
+	v.check("WorkerMaxRetries", validateNotNegative(c.WorkerMaxRetries))
+	v.check("WorkerRetryBackoff", validatePositive(c.WorkerRetryBackoff))
+	v.check("WorkerRetryMaxBackoff", validateMaxBackoff(c.WorkerRetryBackoff, c.WorkerRetryMaxBackoff))
+	v.check("HatchetServerURL", validateHTTPURL(c.HatchetServerURL))
+	v.check("HatchetHostPort", validateHostPort(c.HatchetHostPort))
+	v.check("CORSAllowOrigins", validateCORSOrigins(c.CORSAllowOrigins, c.AppEnv))
+	v.check("HatchetToken", validateToken(c.HatchetToken, c.AppEnv))
+	v.check("HatchetTLSStrategy", validateTLSStrategy(c.HatchetTLSStrategy, c.AppEnv))
+	v.check("HatchetTLSRootCAFile", validateTLSFile(c.HatchetTLSRootCAFile, c.HatchetTLSStrategy == "mtls"))
+	v.check("HatchetTLSCertFile", validateTLSFile(c.HatchetTLSCertFile, c.HatchetTLSStrategy == "mtls" || c.HatchetTLSKeyFile != ""))
+	v.check("HatchetTLSKeyFile", validateTLSFile(c.HatchetTLSKeyFile, c.HatchetTLSStrategy == "mtls" || c.HatchetTLSCertFile != ""))
+	v.check("WorkflowSchemaDir", validateDir(c.WorkflowSchemaDir))
+	v.check("RunMaxBodyBytes", validatePositiveInt(c.RunMaxBodyBytes))
+	v.check("EventKeys", validatePatterns(c.EventKeys))
+	v.check("EventMaxPayloadBytes", validatePositiveInt(c.EventMaxPayloadBytes))
+	v.check("EventMaxBulkEvents", validatePositiveInt(c.EventMaxBulkEvents))
+	v.check("DatabaseURL", validateDatabaseURL(c.DatabaseURL))
+
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: v.errs}
+}
+
+type validator struct {
+	envs map[string]string
+	errs []*FieldError
+}
+
+func (v *validator) check(field string, err error) {
+	if err != nil {
+		v.errs = append(v.errs, &FieldError{Field: field, Env: v.envs[field], Err: err})
+	}
+}
+
+func validatePort(port int) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("port %d is outside 1-65535", port)
+	}
+	return nil
+}
+
+func validatePositive(d time.Duration) error {
+	if d <= 0 {
+		return fmt.Errorf("%s must be positive", d)
+	}
+	return nil
+}
+
+func validateNotNegative(n int) error {
+	if n < 0 {
+		return fmt.Errorf("%d must not be negative", n)
+	}
+	return nil
+}
+
+func validatePositiveInt(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("%d must be positive", n)
+	}
+	return nil
+}
+
+// validatePatterns checks each entry is a valid path.Match pattern
+func validatePatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("%q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+func validateMaxBackoff(backoff, maxBackoff time.Duration) error {
+	if maxBackoff < backoff {
+		return fmt.Errorf("%s is less than the initial backoff %s", maxBackoff, backoff)
+	}
+	return nil
+}
+
+func validateNotEmpty(s string) error {
+	if s == "" {
+		return errors.New("must not be empty")
+	}
+	return nil
+}
+
+func validateOneOf(s string, allowed []string) error {
+	for _, a := range allowed {
+		if s == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not one of %s", s, strings.Join(allowed, ", "))
+}
+
+func validateHTTPURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%q must be an http or https URL", s)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%q has no host", s)
+	}
+	return nil
+}
+
+func validateHostPort(s string) error {
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		return err
+	}
+	if host == "" {
+		return fmt.Errorf("%q has no host", s)
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return fmt.Errorf("%q has a non-numeric port", s)
+	}
+	return validatePort(n)
+}
+
+func validateCORSOrigins(origins []string, profile Profile) error {
+	if profile != ProfileProd {
+		return nil
+	}
+	for _, o := range origins {
+		if o == "*" {
+			return errors.New(`"*" is not allowed in the prod profile; list the allowed origins`)
+		}
+	}
+	return nil
+}
+
+func validateTLSStrategy(strategy string, profile Profile) error {
+	if err := validateOneOf(strategy, TLSStrategies); err != nil {
+		return err
+	}
+	if profile == ProfileProd && strategy == "none" {
+		return errors.New("the prod profile requires tls or mtls")
+	}
+	return nil
+}
+
+// validateTLSFile checks a TLS file is readable when set, and set when required.
+// A cert and its key are each required once the other is set.
+func validateTLSFile(path string, required bool) error {
+	if path == "" {
+		if required {
+			return errors.New("required for mtls, and with the other half of a cert/key pair")
+		}
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// validateDir checks an optional directory exists
+func validateDir(path string) error {
+	if path == "" {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", path)
+	}
+	return nil
+}
+
+func validateToken(token string, profile Profile) error {
+	if token == "" {
+		return errors.New("must not be empty")
+	}
+	if token == PlaceholderToken {
+		if profile == ProfileTest {
+			return nil
+		}
+		return fmt.Errorf("is the test placeholder, which the %s profile does not accept; set a real token or HATCHET_CLIENT_TOKEN_FILE", profile)
+	}
+	if _, err := apitoken.ParseClaims(token); err != nil {
+		return fmt.Errorf("not a Hatchet API token: %w", err)
+	}
+	return nil
+}
+
+// validateDatabaseURL accepts an empty value, a postgres:// URL or a key=value connection string
+func validateDatabaseURL(dsn string) error {
+	if dsn == "" {
+		return nil
+	}
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			// url errors quote the input, which may hold a password
+			return errors.New("not a valid URL")
+		}
+		if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+			return fmt.Errorf("scheme %q is not postgres or postgresql", u.Scheme)
+		}
+		if u.Host == "" {
+			return errors.New("URL has no host")
+		}
+		return nil
+	}
+	// pgconn handles quoted values such as password='my secret'; its errors quote the connection string,
+	// so none of it is passed on
+	if _, err := pgconn.ParseConfig(dsn); err != nil {
+		return errors.New("not a valid key=value connection string")
+	}
+	return nil
+}
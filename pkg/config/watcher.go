@@ -0,0 +1,230 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultWatchInterval is how often Watcher checks the config file for changes
+	DefaultWatchInterval = 2 * time.Second
+
+	redacted = "[redacted]"
+)
+
+// Change is one field whose value differs between two configs
+type Change struct {
+	// Field is the AppConfig field name, e.g. "LogLevel"
+	Field    string
+	Old, New any
+	// RestartRequired is set for fields that are not tagged reload:"hot".
+	// Their new value is not applied until the app restarts.
+	RestartRequired bool
+	// Redacted is set for secret fields; String hides their values
+	Redacted bool
+}
+
+// String describes the change, hiding the values of secret fields
+func (c Change) String() string {
+	old, next := c.Old, c.New
+	if c.Redacted {
+		old, next = redacted, redacted
+	}
+	s := fmt.Sprintf("%s changed from %v to %v", c.Field, old, next)
+	if c.RestartRequired {
+		s += " (restart required)"
+	}
+	return s
+}
+
+// ChangeEvent is sent to subscribers after a reload that changed something
+type ChangeEvent struct {
+	// Config is the snapshot now in effect
+	Config  *AppConfig
+	Changes []Change
+}
+
+// RestartRequired returns the changes that wait for a restart
+func (e ChangeEvent) RestartRequired() []Change {
+	var out []Change
+	for _, c := range e.Changes {
+		if c.RestartRequired {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Watcher holds the current configuration and reloads it on SIGHUP or when the config file changes.
+// A reloaded config must pass Validate before it is swapped in; otherwise the current one stays.
+// Only fields tagged reload:"hot" take their new value; the rest keep their running value and are
+// reported as requiring a restart.
+type Watcher struct {
+	load     func() (*AppConfig, error)
+	current  atomic.Pointer[AppConfig]
+	interval time.Duration
+	// loaded is the last config load returned, including changes still waiting for a restart
+	loaded *AppConfig
+
+	mu          sync.Mutex
+	subscribers []func(ChangeEvent)
+	onError     func(error)
+}
+
+// NewWatcher calls load for the initial config and validates it. load is called again on every reload,
+// so it should re-read the environment, e.g. Load(WithEnviron(os.Environ()), WithArgs(args)).
+func NewWatcher(load func() (*AppConfig, error)) (*Watcher, error) {
+	cfg, err := load()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{load: load, interval: DefaultWatchInterval, loaded: cfg}
+	w.current.Store(cfg)
+	return w, nil
+}
+
+// Config returns the current snapshot. It must not be modified.
+func (w *Watcher) Config() *AppConfig {
+	return w.current.Load()
+}
+
+// Subscribe registers fn to be called, in order of registration, after every reload that changed something
+func (w *Watcher) Subscribe(fn func(ChangeEvent)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// OnError registers fn to be called when a reload triggered by Run fails
+func (w *Watcher) OnError(fn func(error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onError = fn
+}
+
+// Reload loads and validates the config, swaps in the new snapshot and notifies subscribers.
+// The snapshot mixes new hot fields with running restart-required ones, so it is validated as well.
+// On error the current snapshot is kept. Subscribers are called without the watcher locked, so they may
+// call Subscribe or OnError.
+func (w *Watcher) Reload() (ChangeEvent, error) {
+	next, err := w.load()
+	if err != nil {
+		return ChangeEvent{}, fmt.Errorf("failed to reload config: %w", err)
+	}
+	if err := next.Validate(); err != nil {
+		return ChangeEvent{}, fmt.Errorf("reloaded config is invalid: %w", err)
+	}
+
+	w.mu.Lock()
+	applied, changes := merge(w.current.Load(), w.loaded, next)
+	event := ChangeEvent{Config: applied, Changes: changes}
+	if len(changes) == 0 {
+		w.loaded = next
+		w.mu.Unlock()
+		return event, nil
+	}
+	if err := applied.Validate(); err != nil {
+		w.mu.Unlock()
+		return ChangeEvent{}, fmt.Errorf("reloaded config is invalid with the running restart-required fields: %w", err)
+	}
+	w.loaded = next
+	w.current.Store(applied)
+	subscribers := slices.Clone(w.subscribers)
+	w.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(event)
+	}
+	return event, nil
+}
+
+// Run reloads on SIGHUP and whenever the config file's size or modification time changes, until ctx is done
+func (w *Watcher) Run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	last := fileState(w.Config().ConfigFile())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		case <-ticker.C:
+			state := fileState(w.Config().ConfigFile())
+			if state == last {
+				continue
+			}
+			last = state
+		}
+
+		if _, err := w.Reload(); err != nil {
+			w.mu.Lock()
+			onError := w.onError
+			w.mu.Unlock()
+			if onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// merge returns the config to run with after a reload: next, but with every field that needs a restart
+// still at its running value in current. It also lists what differs between the previous load and next,
+// so a change waiting for a restart is reported once.
+func merge(current, prev, next *AppConfig) (*AppConfig, []Change) {
+	applied := *next
+	applied.sources = next.Sources()
+	dst, running := reflect.ValueOf(&applied).Elem(), reflect.ValueOf(current).Elem()
+	old, src := reflect.ValueOf(prev).Elem(), reflect.ValueOf(next).Elem()
+
+	var changes []Change
+	for _, f := range fields() {
+		if !f.hot {
+			dst.Field(f.index).Set(running.Field(f.index))
+			applied.setSource(f.name, current.Source(f.name))
+		}
+
+		o, n := old.Field(f.index), src.Field(f.index)
+		if reflect.DeepEqual(o.Interface(), n.Interface()) {
+			continue
+		}
+		changes = append(changes, Change{
+			Field:           f.name,
+			Old:             o.Interface(),
+			New:             n.Interface(),
+			RestartRequired: !f.hot,
+			Redacted:        f.redact != "",
+		})
+	}
+	return &applied, changes
+}
+
+type fileStat struct {
+	size    int64
+	modTime time.Time
+}
+
+// fileState identifies a version of a file; a missing file has the zero state
+func fileState(path string) fileStat {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStat{}
+	}
+	return fileStat{size: info.Size(), modTime: info.ModTime()}
+}
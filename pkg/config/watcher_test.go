@@ -0,0 +1,132 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// watchedConfig returns a valid config file for the dev profile
+func watchedConfig(logLevel string, port int) string {
+	return fmt.Sprintf("logLevel: %s\nport: %d\nhostPort: localhost:7077\ntoken: %s\n", logLevel, port, testToken)
+}
+
+func newFileWatcher(t *testing.T, logLevel string, port int) (*Watcher, string) {
+	t.Helper()
+	path := writeConfigFile(t, "config.yaml", watchedConfig(logLevel, port))
+	w, err := NewWatcher(func() (*AppConfig, error) {
+		return Load(WithFile(path), WithEnviron(nil))
+	})
+	require.NoError(t, err)
+	return w, path
+}
+
+func TestWatcherReloadAppliesHotFields(t *testing.T) {
+	w, path := newFileWatcher(t, "info", 8080)
+	var events []ChangeEvent
+	w.Subscribe(func(e ChangeEvent) { events = append(events, e) })
+
+	require.NoError(t, os.WriteFile(path, []byte(watchedConfig("warn", 9090)), 0o600))
+	event, err := w.Reload()
+	require.NoError(t, err)
+
+	assert.Equal(t, []Change{
+		{Field: "Port", Old: 8080, New: 9090, RestartRequired: true},
+		{Field: "LogLevel", Old: "info", New: "warn"},
+	}, event.Changes)
+	assert.Equal(t, []Change{event.Changes[0]}, event.RestartRequired())
+	assert.Len(t, events, 1)
+	assert.Equal(t, "Port changed from 8080 to 9090 (restart required)", event.Changes[0].String())
+
+	cfg := w.Config()
+	assert.Equal(t, "warn", cfg.LogLevel, "hot field is swapped in")
+	assert.Equal(t, 8080, cfg.Port, "restart field keeps its running value")
+	assert.Same(t, event.Config, cfg)
+
+	_, err = w.Reload()
+	require.NoError(t, err)
+	assert.Len(t, events, 1, "subscribers are not called when nothing changed")
+}
+
+func TestWatcherKeepsConfigOnInvalidReload(t *testing.T) {
+	w, path := newFileWatcher(t, "info", 8080)
+	before := w.Config()
+
+	require.NoError(t, os.WriteFile(path, []byte(watchedConfig("loud", 8080)), 0o600))
+	_, err := w.Reload()
+	assert.ErrorContains(t, err, "LOG_LEVEL")
+	assert.Same(t, before, w.Config())
+}
+
+func TestWatcherValidatesAppliedConfig(t *testing.T) {
+	// The running schema directory is removed, and the reload no longer sets one; the restart-required
+	// field keeps its running value, which is now invalid
+	dir := t.TempDir()
+	logLevel, schemaDir := "info", dir
+	w, err := NewWatcher(func() (*AppConfig, error) {
+		cfg, err := Load(WithEnviron([]string{"HATCHET_CLIENT_TOKEN=" + testToken, "HATCHET_CLIENT_HOST_PORT=localhost:7077"}))
+		if err == nil {
+			cfg.LogLevel, cfg.WorkflowSchemaDir = logLevel, schemaDir
+		}
+		return cfg, err
+	})
+	require.NoError(t, err)
+	before := w.Config()
+
+	require.NoError(t, os.Remove(dir))
+	logLevel, schemaDir = "warn", ""
+	_, err = w.Reload()
+	assert.ErrorContains(t, err, "WORKFLOW_SCHEMA_DIR")
+	assert.Same(t, before, w.Config())
+}
+
+func TestWatcherSubscriberMaySubscribe(t *testing.T) {
+	w, path := newFileWatcher(t, "info", 8080)
+	var nested int
+	w.Subscribe(func(ChangeEvent) {
+		w.Subscribe(func(ChangeEvent) { nested++ })
+		w.OnError(func(error) {})
+	})
+
+	require.NoError(t, os.WriteFile(path, []byte(watchedConfig("warn", 8080)), 0o600))
+	_, err := w.Reload()
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte(watchedConfig("error", 8080)), 0o600))
+	_, err = w.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, 1, nested, "a subscriber added during a reload is called from the next one")
+}
+
+func TestWatcherRunSeesFileChange(t *testing.T) {
+	w, path := newFileWatcher(t, "info", 8080)
+	w.interval = 10 * time.Millisecond
+	events := make(chan ChangeEvent, 1)
+	w.Subscribe(func(e ChangeEvent) { events <- e })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	// Let Run record the current file state, then change its size so the edit is seen
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, os.WriteFile(path, []byte(watchedConfig("error", 8080)+"\n"), 0o600))
+
+	select {
+	case e := <-events:
+		assert.Equal(t, "error", e.Config.LogLevel)
+	case <-time.After(2 * time.Second):
+		t.Fatal("config file change was not noticed")
+	}
+}
+
+func TestChangeStringRedactsSecrets(t *testing.T) {
+	c := Change{Field: "HatchetToken", Old: "old-token", New: "new-token", RestartRequired: true, Redacted: true}
+	assert.NotContains(t, c.String(), "new-token")
+	assert.Equal(t, "HatchetToken changed from [redacted] to [redacted] (restart required)", c.String())
+}
@@ -0,0 +1,40 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/hatchet-dev/hatchet/pkg/client"
+	"github.com/jackc/pgx/v5"
+)
+
+// Hatchet checks the Hatchet REST API's readiness endpoint through c
+func Hatchet(c client.Client) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		resp, err := c.API().ReadinessGetWithResponse(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to reach Hatchet: %w", err)
+		}
+		if resp.StatusCode() != http.StatusOK {
+			return fmt.Errorf("hatchet is not ready: %s", resp.Status())
+		}
+		return nil
+	})
+}
+
+// Postgres connects to the database at dsn, a URL or key=value connection string, and pings it
+func Postgres(dsn string) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		conn, err := pgx.Connect(ctx, dsn)
+		if err != nil {
+			// pgx redacts the password from connection strings it quotes in errors
+			return fmt.Errorf("failed to connect to Postgres: %w", err)
+		}
+		defer conn.Close(context.Background())
+		if err := conn.Ping(ctx); err != nil {
+			return fmt.Errorf("failed to ping Postgres: %w", err)
+		}
+		return nil
+	})
+}
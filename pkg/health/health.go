@@ -0,0 +1,188 @@
+// Package health serves /livez and /readyz from a registry of pluggable checks
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DefaultTimeout bounds each check when the registry has no timeout of its own
+const DefaultTimeout = 2 * time.Second
+
+// Checker checks one dependency; a nil error means it is usable
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// Detailer is implemented by checkers that add details, such as a state snapshot, to their results
+type Detailer interface {
+	Details() any
+}
+
+// CheckerFunc adapts a function to Checker
+type CheckerFunc func(ctx context.Context) error
+
+// Check implements Checker
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Kind says which probes a check takes part in
+type Kind int
+
+const (
+	// Readiness checks decide whether the process should receive traffic
+	Readiness Kind = 1 << iota
+	// Liveness checks decide whether the process should be restarted; keep them to the process itself
+	Liveness
+)
+
+// Statuses of a check and of a report
+const (
+	StatusOK      = "ok"
+	StatusFailing = "failing"
+)
+
+// Result is the outcome of one check
+type Result struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Latency string `json:"latency"`
+	// Error is why the check is failing now
+	Error string `json:"error,omitempty"`
+	// LastError and LastErrorAt are kept after the check recovers, to show recent flapping
+	LastError   string     `json:"lastError,omitempty"`
+	LastErrorAt *time.Time `json:"lastErrorAt,omitempty"`
+	// Details is what the checker adds to its result when it implements Detailer
+	Details any `json:"details,omitempty"`
+}
+
+// Report is the response of /livez and /readyz
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+type check struct {
+	name    string
+	checker Checker
+	kind    Kind
+
+	// guarded by Registry.mu
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// Registry holds the checks behind /livez and /readyz
+type Registry struct {
+	timeout time.Duration
+
+	mu     sync.Mutex
+	checks []*check
+}
+
+// NewRegistry returns an empty registry that gives each check timeout to answer, or DefaultTimeout if zero
+func NewRegistry(timeout time.Duration) *Registry {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Registry{timeout: timeout}
+}
+
+// Register adds a check under name to the probes in kind, e.g. Readiness or Readiness|Liveness
+func (r *Registry) Register(name string, c Checker, kind Kind) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, &check{name: name, checker: c, kind: kind})
+}
+
+// Run runs the checks of kind concurrently, in registration order in the report.
+// The report is failing if any check is.
+func (r *Registry) Run(ctx context.Context, kind Kind) Report {
+	r.mu.Lock()
+	var checks []*check
+	for _, c := range r.checks {
+		if c.kind&kind != 0 {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.Unlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.run(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: results}
+	for _, res := range results {
+		if res.Status != StatusOK {
+			report.Status = StatusFailing
+		}
+	}
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, c *check) Result {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := safeCheck(ctx, c.checker)
+	res := Result{Name: c.name, Status: StatusOK, Latency: time.Since(start).String()}
+	if d, ok := c.checker.(Detailer); ok {
+		res.Details = d.Details()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		res.Status, res.Error = StatusFailing, err.Error()
+		c.lastError, c.lastErrorAt = err.Error(), time.Now()
+	}
+	if c.lastError != "" {
+		at := c.lastErrorAt
+		res.LastError, res.LastErrorAt = c.lastError, &at
+	}
+	return res
+}
+
+// safeCheck runs a check, turning a panic into a failure
+func safeCheck(ctx context.Context, c Checker) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("check panicked: %v", r)
+		}
+	}()
+	return c.Check(ctx)
+}
+
+// Register adds GET /livez and /readyz to e, and /health as an alias of /readyz for older probes.
+// Each answers 200 with the report when every check of its kind passes and 503 otherwise.
+// /livez with no liveness checks only shows the server is serving.
+func Register(e *echo.Echo, r *Registry) {
+	e.GET("/livez", handler(r, Liveness))
+	e.GET("/readyz", handler(r, Readiness))
+	e.GET("/health", handler(r, Readiness))
+}
+
+func handler(r *Registry, kind Kind) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		report := r.Run(c.Request().Context(), kind)
+		code := http.StatusOK
+		if report.Status != StatusOK {
+			code = http.StatusServiceUnavailable
+		}
+		return c.JSON(code, report)
+	}
+}
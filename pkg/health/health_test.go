@@ -0,0 +1,99 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryRun(t *testing.T) {
+	var failing error = errors.New("connection refused")
+	r := NewRegistry(50 * time.Millisecond)
+	r.Register("flaky", CheckerFunc(func(context.Context) error { return failing }), Readiness)
+	r.Register("self", CheckerFunc(func(context.Context) error { return nil }), Readiness|Liveness)
+
+	report := r.Run(context.Background(), Readiness)
+	assert.Equal(t, StatusFailing, report.Status)
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, "flaky", report.Checks[0].Name)
+	assert.Equal(t, "connection refused", report.Checks[0].Error)
+	assert.Equal(t, StatusOK, report.Checks[1].Status)
+
+	live := r.Run(context.Background(), Liveness)
+	assert.Equal(t, StatusOK, live.Status)
+	require.Len(t, live.Checks, 1, "only liveness checks run for /livez")
+
+	failing = nil
+	report = r.Run(context.Background(), Readiness)
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Empty(t, report.Checks[0].Error)
+	assert.Equal(t, "connection refused", report.Checks[0].LastError, "the last error is kept after recovery")
+	assert.NotNil(t, report.Checks[0].LastErrorAt)
+}
+
+// detailedCheck passes and reports a fixed state as its details
+type detailedCheck struct{}
+
+func (detailedCheck) Check(context.Context) error { return nil }
+func (detailedCheck) Details() any                { return map[string]any{"state": "running", "restarts": 2} }
+
+func TestRegistryDetails(t *testing.T) {
+	r := NewRegistry(0)
+	r.Register("worker", detailedCheck{}, Readiness)
+	r.Register("plain", CheckerFunc(func(context.Context) error { return nil }), Readiness)
+
+	report := r.Run(context.Background(), Readiness)
+	assert.Equal(t, map[string]any{"state": "running", "restarts": 2}, report.Checks[0].Details)
+	assert.Nil(t, report.Checks[1].Details)
+}
+
+func TestRegistryTimeoutAndPanic(t *testing.T) {
+	r := NewRegistry(20 * time.Millisecond)
+	r.Register("slow", CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}), Readiness)
+	r.Register("broken", CheckerFunc(func(context.Context) error { panic("nil map") }), Readiness)
+
+	report := r.Run(context.Background(), Readiness)
+	assert.Equal(t, StatusFailing, report.Status)
+	assert.Contains(t, report.Checks[0].Error, "deadline exceeded")
+	assert.Equal(t, "check panicked: nil map", report.Checks[1].Error)
+}
+
+func TestHandlers(t *testing.T) {
+	ready := errors.New("not yet")
+	r := NewRegistry(0)
+	r.Register("hatchet", CheckerFunc(func(context.Context) error { return ready }), Readiness)
+
+	e := echo.New()
+	Register(e, r)
+
+	get := func(path string) (int, Report) {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var report Report
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		return rec.Code, report
+	}
+
+	code, report := get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusFailing, report.Status)
+
+	code, _ = get("/livez")
+	assert.Equal(t, http.StatusOK, code, "a failing dependency does not fail liveness")
+
+	ready = nil
+	code, report = get("/health")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusOK, report.Status)
+}
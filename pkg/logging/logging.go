@@ -0,0 +1,100 @@
+// Package logging builds the structured logger shared by the server, the worker and the test suite.
+// Records logged with a context carry the request ID, workflow run ID and step stored in it.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+)
+
+// Output formats accepted by New
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Formats are the accepted values of AppConfig.LogFormat
+var Formats = []string{FormatText, FormatJSON}
+
+// Keys of the fields taken from the context
+const (
+	RequestIDKey = "request_id"
+	RunIDKey     = "workflow_run_id"
+	StepKey      = "step"
+)
+
+// ParseLevel maps a LogLevel (debug, info, warn or error) to a slog level
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(s))
+	return level, err
+}
+
+// New returns a logger writing format (text or json) to w. Pass a *slog.LevelVar as level
+// to change it while the app runs.
+func New(w io.Writer, format string, level slog.Leveler) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler
+	if format == FormatJSON {
+		h = slog.NewJSONHandler(w, opts)
+	} else {
+		h = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(contextHandler{h})
+}
+
+type fieldsKey struct{}
+
+// WithRequestID returns a context whose log records carry the HTTP request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return withField(ctx, slog.String(RequestIDKey, id))
+}
+
+// WithRunID returns a context whose log records carry the workflow run ID
+func WithRunID(ctx context.Context, runID string) context.Context {
+	return withField(ctx, slog.String(RunIDKey, runID))
+}
+
+// WithStep returns a context whose log records carry the step name
+func WithStep(ctx context.Context, step string) context.Context {
+	return withField(ctx, slog.String(StepKey, step))
+}
+
+// Fields returns the correlation fields stored in ctx
+func Fields(ctx context.Context) []slog.Attr {
+	fields, _ := ctx.Value(fieldsKey{}).([]slog.Attr)
+	return fields
+}
+
+// withField adds a to the fields in ctx, replacing one with the same key
+func withField(ctx context.Context, a slog.Attr) context.Context {
+	fields := Fields(ctx)
+	out := make([]slog.Attr, 0, len(fields)+1)
+	for _, f := range fields {
+		if f.Key != a.Key {
+			out = append(out, f)
+		}
+	}
+	return context.WithValue(ctx, fieldsKey{}, append(out, a))
+}
+
+// contextHandler adds the fields stored in a record's context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		r.AddAttrs(Fields(ctx)...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
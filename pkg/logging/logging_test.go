@@ -0,0 +1,139 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hatchet-dev/hatchet/pkg/worker"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// records decodes the JSON lines written by a logger
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var r map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &r), line)
+		out = append(out, r)
+	}
+	return out
+}
+
+func TestContextFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, FormatJSON, slog.LevelInfo)
+
+	ctx := WithStep(WithRunID(WithRequestID(context.Background(), "req-1"), "run-1"), "first")
+	ctx = WithStep(ctx, "second")
+	logger.InfoContext(ctx, "hello", "n", 1)
+	logger.Info("no context")
+
+	got := records(t, &buf)
+	require.Len(t, got, 2)
+	assert.Equal(t, "req-1", got[0][RequestIDKey])
+	assert.Equal(t, "run-1", got[0][RunIDKey])
+	assert.Equal(t, "second", got[0][StepKey], "a later step replaces the earlier one")
+	assert.NotContains(t, got[1], RequestIDKey)
+}
+
+func TestLevel(t *testing.T) {
+	var buf bytes.Buffer
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelWarn)
+	logger := New(&buf, FormatText, level)
+
+	logger.Info("hidden")
+	level.Set(slog.LevelDebug)
+	logger.Debug("shown")
+	assert.NotContains(t, buf.String(), "hidden")
+	assert.Contains(t, buf.String(), "msg=shown")
+
+	_, err := ParseLevel("loud")
+	assert.Error(t, err)
+}
+
+func TestEchoMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, FormatJSON, slog.LevelInfo)
+
+	e := echo.New()
+	e.Use(Echo(logger))
+	e.GET("/ok", func(c echo.Context) error {
+		logger.InfoContext(c.Request().Context(), "handling")
+		return c.NoContent(http.StatusNoContent)
+	})
+	e.GET("/fail", func(c echo.Context) error {
+		return errors.New("boom")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/ok", nil)
+	req.Header.Set(echo.HeaderXRequestID, "from-client")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, "from-client", rec.Header().Get(echo.HeaderXRequestID))
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fail", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	generated := rec.Header().Get(echo.HeaderXRequestID)
+	assert.NotEmpty(t, generated)
+
+	got := records(t, &buf)
+	require.Len(t, got, 3)
+	assert.Equal(t, "handling", got[0]["msg"])
+	assert.Equal(t, "from-client", got[0][RequestIDKey], "handler logs carry the request ID")
+	assert.Equal(t, "from-client", got[1][RequestIDKey])
+	assert.EqualValues(t, http.StatusNoContent, got[1]["status"])
+	assert.Equal(t, "ERROR", got[2]["level"])
+	assert.Equal(t, generated, got[2][RequestIDKey])
+	assert.Equal(t, "boom", got[2]["error"])
+}
+
+// fakeStepContext implements the parts of worker.HatchetContext the middleware uses
+type fakeStepContext struct {
+	worker.HatchetContext
+	ctx context.Context
+}
+
+func (f *fakeStepContext) SetContext(ctx context.Context)          { f.ctx = ctx }
+func (f *fakeStepContext) GetContext() context.Context             { return f.ctx }
+func (f *fakeStepContext) Deadline() (deadline time.Time, ok bool) { return f.ctx.Deadline() }
+func (f *fakeStepContext) Done() <-chan struct{}                   { return f.ctx.Done() }
+func (f *fakeStepContext) Err() error                              { return f.ctx.Err() }
+func (f *fakeStepContext) Value(key any) any                       { return f.ctx.Value(key) }
+func (f *fakeStepContext) WorkflowRunId() string                   { return "run-1" }
+func (f *fakeStepContext) StepName() string                        { return "charge" }
+
+func TestWorkerMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, FormatJSON, slog.LevelDebug)
+	mw := Worker(logger)
+
+	err := mw(&fakeStepContext{ctx: context.Background()}, func(ctx worker.HatchetContext) error {
+		logger.InfoContext(ctx, "inside step")
+		return errors.New("declined")
+	})
+	assert.EqualError(t, err, "declined")
+
+	got := records(t, &buf)
+	require.Len(t, got, 3)
+	for _, r := range got {
+		assert.Equal(t, "run-1", r[RunIDKey])
+		assert.Equal(t, "charge", r[StepKey])
+	}
+	assert.Equal(t, "step failed", got[2]["msg"])
+}
@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hatchet-dev/hatchet/pkg/worker"
+	"github.com/labstack/echo/v4"
+)
+
+// Echo assigns each request an ID, reusing an incoming X-Request-Id, echoes it in the response
+// and stores it in the request context. Each request is logged once it completes: server errors
+// at error level, everything else at info.
+func Echo(logger *slog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			id := req.Header.Get(echo.HeaderXRequestID)
+			if id == "" {
+				id = uuid.NewString()
+			}
+			c.Response().Header().Set(echo.HeaderXRequestID, id)
+			ctx := WithRequestID(req.Context(), id)
+			c.SetRequest(req.WithContext(ctx))
+
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				// Let Echo write the error response so the logged status is the one sent
+				c.Error(err)
+			}
+
+			status := c.Response().Status
+			attrs := []slog.Attr{
+				slog.String("method", req.Method),
+				slog.String("uri", req.RequestURI),
+				slog.Int("status", status),
+				slog.Duration("latency", time.Since(start)),
+				slog.String("remote_ip", c.RealIP()),
+			}
+			level := slog.LevelInfo
+			if status >= 500 {
+				level = slog.LevelError
+			}
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+			}
+			logger.LogAttrs(ctx, level, "request", attrs...)
+			return nil
+		}
+	}
+}
+
+// Worker adds the workflow run ID and step name to each step's context, so anything the step
+// logs with it carries them, and logs when steps start and finish
+func Worker(logger *slog.Logger) worker.MiddlewareFunc {
+	return func(ctx worker.HatchetContext, next func(worker.HatchetContext) error) error {
+		ctx.SetContext(WithStep(WithRunID(ctx.GetContext(), ctx.WorkflowRunId()), ctx.StepName()))
+
+		logger.DebugContext(ctx, "step started")
+		start := time.Now()
+		err := next(ctx)
+		if err != nil {
+			logger.ErrorContext(ctx, "step failed", "duration", time.Since(start), "error", err)
+			return err
+		}
+		logger.DebugContext(ctx, "step completed", "duration", time.Since(start))
+		return nil
+	}
+}
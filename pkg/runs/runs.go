@@ -0,0 +1,160 @@
+package runs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hatchet-dev/hatchet/pkg/client"
+	"github.com/hatchet-dev/hatchet/pkg/client/rest"
+)
+
+// ErrRunNotFound is returned when Hatchet has no run with the given ID
+var ErrRunNotFound = errors.New("workflow run not found")
+
+// ErrStepNotFound is returned when a run has no task for the given step
+var ErrStepNotFound = errors.New("step not found in workflow run")
+
+// defaultPollInterval is how often Wait checks the run status
+const defaultPollInterval = 250 * time.Millisecond
+
+// IsTerminal reports whether a run in this status will not change any more
+func IsTerminal(status rest.V1TaskStatus) bool {
+	switch status {
+	case rest.V1TaskStatusCOMPLETED, rest.V1TaskStatusFAILED, rest.V1TaskStatusCANCELLED:
+		return true
+	default:
+		return false
+	}
+}
+
+// Details fetches a workflow run with its tasks and events through the Hatchet REST API
+func Details(ctx context.Context, c client.Client, runID string) (*rest.V1WorkflowRunDetails, error) {
+	id, err := uuid.Parse(runID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid run ID %q: %w", runID, err)
+	}
+
+	resp, err := c.API().V1WorkflowRunGetWithResponse(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow run %s: %w", runID, err)
+	}
+	if resp.StatusCode() == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrRunNotFound, runID)
+	}
+	if resp.JSON200 == nil {
+		return nil, fmt.Errorf("failed to get workflow run %s: %s", runID, resp.Status())
+	}
+	return resp.JSON200, nil
+}
+
+// Status fetches only the status of a workflow run
+func Status(ctx context.Context, c client.Client, runID string) (rest.V1TaskStatus, error) {
+	id, err := uuid.Parse(runID)
+	if err != nil {
+		return "", fmt.Errorf("invalid run ID %q: %w", runID, err)
+	}
+
+	resp, err := c.API().V1WorkflowRunGetStatusWithResponse(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to get workflow run status %s: %w", runID, err)
+	}
+	if resp.StatusCode() == http.StatusNotFound {
+		return "", fmt.Errorf("%w: %s", ErrRunNotFound, runID)
+	}
+	if resp.JSON200 == nil {
+		return "", fmt.Errorf("failed to get workflow run status %s: %s", runID, resp.Status())
+	}
+	return *resp.JSON200, nil
+}
+
+// Wait polls a workflow run until it reaches a terminal status or ctx is done, then returns its details.
+// A run that is not visible yet is retried, since Hatchet records runs asynchronously.
+func Wait(ctx context.Context, c client.Client, runID string) (*rest.V1WorkflowRunDetails, error) {
+	ticker := time.NewTicker(defaultPollInterval)
+	defer ticker.Stop()
+
+	for {
+		status, err := Status(ctx, c, runID)
+		switch {
+		case err == nil && IsTerminal(status):
+			return Details(ctx, c, runID)
+		case err != nil && !errors.Is(err, ErrRunNotFound):
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return nil, fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+			}
+			return nil, fmt.Errorf("%w: run %s still %s", ctx.Err(), runID, status)
+		case <-ticker.C:
+		}
+	}
+}
+
+// FindStep returns the task that ran the step with the given readable ID
+func FindStep(details *rest.V1WorkflowRunDetails, step string) (*rest.V1TaskSummary, error) {
+	for i := range details.Tasks {
+		if StepName(details.Tasks[i]) == step {
+			return &details.Tasks[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrStepNotFound, step)
+}
+
+// StepName recovers a task's step readable ID. Action IDs look like "workflow:step",
+// and display names like "step-<unix timestamp>".
+func StepName(task rest.V1TaskSummary) string {
+	if task.ActionId != nil {
+		if i := strings.LastIndex(*task.ActionId, ":"); i >= 0 {
+			return (*task.ActionId)[i+1:]
+		}
+	}
+	name := task.DisplayName
+	if i := strings.LastIndex(name, "-"); i >= 0 && isDigits(name[i+1:]) {
+		return name[:i]
+	}
+	return name
+}
+
+// StepOutput returns the JSON output of a step
+func StepOutput(details *rest.V1WorkflowRunDetails, step string) (json.RawMessage, error) {
+	task, err := FindStep(details, step)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(task.Output)
+}
+
+// ErrorMessage collects the run's error and every task error into one string
+func ErrorMessage(details *rest.V1WorkflowRunDetails) string {
+	var msgs []string
+	if details.Run.ErrorMessage != nil && *details.Run.ErrorMessage != "" {
+		msgs = append(msgs, *details.Run.ErrorMessage)
+	}
+	for _, task := range details.Tasks {
+		if task.ErrorMessage != nil && *task.ErrorMessage != "" {
+			msgs = append(msgs, fmt.Sprintf("%s: %s", StepName(task), *task.ErrorMessage))
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
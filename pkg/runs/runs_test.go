@@ -0,0 +1,37 @@
+package runs
+
+import (
+	"testing"
+
+	"github.com/hatchet-dev/hatchet/pkg/client/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepName(t *testing.T) {
+	actionID := "order-workflow:charge-card"
+	assert.Equal(t, "charge-card", StepName(rest.V1TaskSummary{ActionId: &actionID, DisplayName: "ignored"}))
+	assert.Equal(t, "charge-card", StepName(rest.V1TaskSummary{DisplayName: "charge-card-1760000000"}))
+	assert.Equal(t, "charge-card", StepName(rest.V1TaskSummary{DisplayName: "charge-card"}))
+}
+
+func TestStepOutputAndErrorMessage(t *testing.T) {
+	failure := "card declined"
+	runFailure := "workflow failed"
+	details := &rest.V1WorkflowRunDetails{
+		Run: rest.V1WorkflowRun{ErrorMessage: &runFailure},
+		Tasks: []rest.V1TaskSummary{
+			{DisplayName: "reserve-1760000000", Output: map[string]interface{}{"reserved": true}},
+			{DisplayName: "charge-1760000001", ErrorMessage: &failure},
+		},
+	}
+
+	out, err := StepOutput(details, "reserve")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"reserved": true}`, string(out))
+
+	_, err = StepOutput(details, "ship")
+	assert.ErrorIs(t, err, ErrStepNotFound)
+
+	assert.Equal(t, "workflow failed; charge: card declined", ErrorMessage(details))
+}
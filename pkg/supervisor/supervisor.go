@@ -0,0 +1,378 @@
+// Package supervisor runs the server's Hatchet worker, restarts it when it fails and stops it without
+// cutting off running steps
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hatchet-dev/hatchet/pkg/client"
+	"github.com/hatchet-dev/hatchet/pkg/client/rest"
+	"github.com/hatchet-dev/hatchet/pkg/worker"
+)
+
+const (
+	// drainPollInterval is how often Drain checks whether the last step has finished
+	drainPollInterval = 50 * time.Millisecond
+	// activePollInterval is how often a starting worker is looked up in Hatchet
+	activePollInterval = time.Second
+
+	defaultStartTimeout = 30 * time.Second
+)
+
+// State is where the worker is in its lifecycle
+type State string
+
+const (
+	// StateStarting means the worker is connecting and Hatchet does not list it as active yet
+	StateStarting State = "starting"
+	// StateRunning means Hatchet lists the worker as active
+	StateRunning State = "running"
+	// StateFailed means the last attempt failed; the worker is waiting to retry or has given up
+	StateFailed State = "failed"
+	// StateStopped means the worker was stopped on purpose
+	StateStopped State = "stopped"
+)
+
+// errStartTimeout ends an attempt that did not become active within the start timeout
+var errStartTimeout = errors.New("worker did not become active in time")
+
+// Status is a snapshot of the worker's state, suitable for health endpoints
+type Status struct {
+	State State `json:"state"`
+	// Since is when the worker entered State
+	Since time.Time `json:"since"`
+	// Restarts counts the attempts after the first
+	Restarts int `json:"restarts"`
+	// LastError is the error that ended the last failed attempt
+	LastError string `json:"lastError,omitempty"`
+	// InFlight is the number of step runs executing
+	InFlight int `json:"inFlight"`
+}
+
+// Option configures a Supervisor
+type Option func(*Supervisor)
+
+// WithRetry restarts a failed worker up to maxRetries times, waiting backoff before the first retry and
+// doubling the wait up to maxBackoff. The count resets once the worker is running again.
+// Without it, Run returns the first failure.
+func WithRetry(maxRetries int, backoff, maxBackoff time.Duration) Option {
+	return func(s *Supervisor) {
+		s.maxRetries, s.backoff, s.maxBackoff = maxRetries, backoff, maxBackoff
+	}
+}
+
+// WithWorkerOpts adds options for the worker New creates, on top of its client and name
+func WithWorkerOpts(opts ...worker.WorkerOpt) Option {
+	return func(s *Supervisor) {
+		s.workerOpts = append(s.workerOpts, opts...)
+	}
+}
+
+// WithStartTimeout sets how long an attempt may take to show up as active in Hatchet before it counts as failed
+func WithStartTimeout(d time.Duration) Option {
+	return func(s *Supervisor) {
+		s.startTimeout = d
+	}
+}
+
+// Supervisor runs a Hatchet worker, tracks its state and counts the step runs it is executing, so shutdown
+// can wait for them. The SDK stops taking new steps when the worker's context is cancelled, but leaves
+// running ones to finish in the background.
+type Supervisor struct {
+	worker     *worker.Worker
+	workerOpts []worker.WorkerOpt
+	client     client.Client
+	name       string
+	logger     *slog.Logger
+
+	maxRetries          int
+	backoff, maxBackoff time.Duration
+	startTimeout        time.Duration
+
+	inFlight atomic.Int64
+
+	mu     sync.Mutex
+	status Status
+	// workerID is the ID Hatchet gave the worker in the current attempt, empty until it registers
+	workerID string
+}
+
+// New creates a worker named name on client c and installs the middleware that tracks its running steps.
+// Register workflows and middleware on Worker before calling Run.
+func New(c client.Client, name string, logger *slog.Logger, opts ...Option) (*Supervisor, error) {
+	s := &Supervisor{
+		client:       c,
+		name:         name,
+		logger:       logger.With("worker", name),
+		startTimeout: defaultStartTimeout,
+		status:       Status{State: StateStarting, Since: time.Now()},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	// The worker learns its ID from the dispatcher it is given, so the supervisor sees it there too
+	workerOpts := append(append([]worker.WorkerOpt{}, s.workerOpts...),
+		worker.WithClient(&registering{Client: c, s: s}), worker.WithName(name))
+	w, err := worker.NewWorker(workerOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create worker %s: %w", name, err)
+	}
+	w.Use(s.track)
+	s.worker = w
+	return s, nil
+}
+
+// Worker returns the supervised worker
+func (s *Supervisor) Worker() *worker.Worker {
+	return s.worker
+}
+
+func (s *Supervisor) track(ctx worker.HatchetContext, next func(worker.HatchetContext) error) error {
+	s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+	return next(ctx)
+}
+
+// InFlight returns the number of step runs currently executing
+func (s *Supervisor) InFlight() int {
+	return int(s.inFlight.Load())
+}
+
+// Status returns the worker's current state
+func (s *Supervisor) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := s.status
+	status.InFlight = s.InFlight()
+	return status
+}
+
+func (s *Supervisor) setWorkerID(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.workerID = id
+}
+
+func (s *Supervisor) currentWorkerID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.workerID
+}
+
+func (s *Supervisor) setState(state State, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.State = state
+	s.status.Since = time.Now()
+	if err != nil {
+		s.status.LastError = err.Error()
+	}
+}
+
+// Check reports an error unless the worker is running, so the supervisor can back a readiness check
+func (s *Supervisor) Check(context.Context) error {
+	status := s.Status()
+	if status.State == StateRunning {
+		return nil
+	}
+	if status.LastError != "" {
+		return fmt.Errorf("worker is %s (last error: %s)", status.State, status.LastError)
+	}
+	return fmt.Errorf("worker is %s", status.State)
+}
+
+// Details returns the worker's status, so readiness reports show its state, restarts and last error
+func (s *Supervisor) Details() any {
+	return s.Status()
+}
+
+// Run runs the worker until ctx is cancelled, which stops it taking new steps. A failed attempt, including
+// one that does not become active within the start timeout, is retried as set by WithRetry; once the
+// retries are used up Run returns the last error.
+func (s *Supervisor) Run(ctx context.Context) error {
+	retries, backoff := 0, s.backoff
+	for {
+		s.setState(StateStarting, nil)
+		s.logger.Info("starting Hatchet worker", "attempt", retries+1)
+
+		running, err := s.attempt(ctx)
+		if ctx.Err() != nil {
+			s.setState(StateStopped, nil)
+			s.logger.Info("Hatchet worker stopped taking new steps")
+			return nil
+		}
+		if err == nil {
+			err = errors.New("worker exited")
+		}
+		s.setState(StateFailed, err)
+
+		if running {
+			retries, backoff = 0, s.backoff
+		}
+		if retries >= s.maxRetries {
+			return fmt.Errorf("hatchet worker failed after %d attempts: %w", retries+1, err)
+		}
+		retries++
+		s.logger.Warn("Hatchet worker failed, retrying", "error", err, "retry", retries, "max_retries", s.maxRetries, "backoff", backoff)
+
+		select {
+		case <-ctx.Done():
+			s.setState(StateStopped, nil)
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, s.maxBackoff)
+
+		s.mu.Lock()
+		s.status.Restarts++
+		s.mu.Unlock()
+	}
+}
+
+// attempt runs the worker once and reports whether it got as far as running
+func (s *Supervisor) attempt(ctx context.Context) (running bool, err error) {
+	attemptCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	// The last attempt's registration may still be listed as active until Hatchet notices it is gone
+	s.setWorkerID("")
+
+	var reached atomic.Bool
+	watched := make(chan struct{})
+	go func() {
+		defer close(watched)
+		if err := s.waitActive(attemptCtx); err != nil {
+			cancel(err)
+			return
+		}
+		reached.Store(true)
+		s.setState(StateRunning, nil)
+		s.logger.Info("Hatchet worker running")
+	}()
+
+	err = s.worker.Run(attemptCtx)
+	cancel(nil)
+	<-watched
+
+	if cause := context.Cause(attemptCtx); errors.Is(cause, errStartTimeout) {
+		err = cause
+	}
+	return reached.Load(), err
+}
+
+// waitActive polls Hatchet until it lists this attempt's worker as active. Other replicas register under
+// the same name, so the worker is matched by the ID it registered with. It returns errStartTimeout, wrapped
+// with the last lookup error, if that takes longer than the start timeout.
+func (s *Supervisor) waitActive(ctx context.Context) error {
+	deadline := time.After(s.startTimeout)
+	ticker := time.NewTicker(activePollInterval)
+	defer ticker.Stop()
+
+	var lastErr error
+	for {
+		if id := s.currentWorkerID(); id != "" {
+			active, err := WorkerIDActive(ctx, s.client, id)
+			if err != nil {
+				lastErr = err
+			}
+			if active {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			if lastErr != nil {
+				return fmt.Errorf("%w (last error: %v)", errStartTimeout, lastErr)
+			}
+			return errStartTimeout
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drain waits for running steps to finish. Call it after the context given to Run is cancelled.
+// It returns an error naming how many steps were still running if ctx ends first.
+func (s *Supervisor) Drain(ctx context.Context) error {
+	if n := s.InFlight(); n > 0 {
+		s.logger.Info("waiting for running steps", "steps", n)
+	}
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		n := s.InFlight()
+		if n == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d step runs still running: %w", n, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// WorkerActive reports whether Hatchet lists a worker named name as active in c's tenant. Any worker with
+// that name counts, so use WorkerIDActive when several processes register the same name.
+func WorkerActive(ctx context.Context, c client.Client, name string) (bool, error) {
+	return anyActive(ctx, c, func(w rest.Worker) bool { return w.Name == name })
+}
+
+// WorkerIDActive reports whether Hatchet lists the worker with the given ID as active in c's tenant
+func WorkerIDActive(ctx context.Context, c client.Client, id string) (bool, error) {
+	return anyActive(ctx, c, func(w rest.Worker) bool { return w.Metadata.Id == id })
+}
+
+func anyActive(ctx context.Context, c client.Client, match func(rest.Worker) bool) (bool, error) {
+	tenantID, err := uuid.Parse(c.TenantId())
+	if err != nil {
+		return false, fmt.Errorf("invalid tenant ID %q: %w", c.TenantId(), err)
+	}
+	resp, err := c.API().WorkerListWithResponse(ctx, tenantID)
+	if err != nil {
+		return false, fmt.Errorf("failed to list workers: %w", err)
+	}
+	if resp.JSON200 == nil || resp.JSON200.Rows == nil {
+		return false, fmt.Errorf("failed to list workers: %s", resp.Status())
+	}
+	for _, row := range *resp.JSON200.Rows {
+		if match(row) && row.Status != nil && *row.Status == rest.ACTIVE {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// registering is the client the supervised worker runs on. It records the worker ID the dispatcher hands
+// out; Worker.ID holds the same value but is set without synchronisation while the worker runs.
+type registering struct {
+	client.Client
+	s *Supervisor
+}
+
+func (r *registering) Dispatcher() client.DispatcherClient {
+	return &registeringDispatcher{DispatcherClient: r.Client.Dispatcher(), s: r.s}
+}
+
+type registeringDispatcher struct {
+	client.DispatcherClient
+	s *Supervisor
+}
+
+func (d *registeringDispatcher) GetActionListener(ctx context.Context, req *client.GetActionListenerRequest) (client.WorkerActionListener, *string, error) {
+	listener, id, err := d.DispatcherClient.GetActionListener(ctx, req)
+	if err == nil && id != nil {
+		d.s.setWorkerID(*id)
+	}
+	return listener, id, err
+}
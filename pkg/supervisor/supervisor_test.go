@@ -0,0 +1,148 @@
+package supervisor
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/arun0009/hatchetest/pkg/config"
+	"github.com/hatchet-dev/hatchet/pkg/client"
+	"github.com/hatchet-dev/hatchet/pkg/client/rest"
+	"github.com/hatchet-dev/hatchet/pkg/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+const testWorkerID = "6a3b6d0e-7a53-4bb0-9a3e-1f6f3c1c8f10"
+
+// unreachableClient returns a client that points at ports nothing listens on
+func unreachableClient(t *testing.T) client.Client {
+	t.Helper()
+	enc := base64.RawURLEncoding.EncodeToString
+	token := enc([]byte(`{"alg":"ES256"}`)) + "." +
+		enc([]byte(`{"sub":"707d0855-80ab-4e1f-a156-f1c4546cbf52","iat":1700000000,"exp":1900000000,`+
+			`"server_url":"http://127.0.0.1:1","grpc_broadcast_address":"127.0.0.1:1"}`)) + ".c2ln"
+	cfg := &config.AppConfig{
+		HatchetServerURL:   "http://127.0.0.1:1",
+		HatchetHostPort:    "127.0.0.1:1",
+		HatchetToken:       token,
+		HatchetTLSStrategy: "none",
+	}
+	c, err := cfg.NewHatchetClient()
+	require.NoError(t, err)
+	return c
+}
+
+// listedClient lists one active worker, with the given name and ID, in place of the tenant's workers
+type listedClient struct {
+	client.Client
+	rest *rest.ClientWithResponses
+}
+
+func (c *listedClient) API() *rest.ClientWithResponses { return c.rest }
+
+func withListedWorker(t *testing.T, c client.Client, name, id string) client.Client {
+	t.Helper()
+	active := rest.ACTIVE
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(rest.WorkerList{Rows: &[]rest.Worker{
+			{Name: name, Status: &active, Metadata: rest.APIResourceMeta{Id: id}},
+		}})
+	}))
+	t.Cleanup(srv.Close)
+	restClient, err := rest.NewClientWithResponses(srv.URL)
+	require.NoError(t, err)
+	return &listedClient{Client: c, rest: restClient}
+}
+
+func TestWorkerIDActive(t *testing.T) {
+	c := withListedWorker(t, unreachableClient(t), "hatchetest-worker", testWorkerID)
+
+	active, err := WorkerActive(context.Background(), c, "hatchetest-worker")
+	require.NoError(t, err)
+	assert.True(t, active)
+
+	active, err = WorkerIDActive(context.Background(), c, testWorkerID)
+	require.NoError(t, err)
+	assert.True(t, active)
+
+	active, err = WorkerIDActive(context.Background(), c, "0d8f1c43-3c1e-4a8e-b1d5-4c1f0a0e2b77")
+	require.NoError(t, err)
+	assert.False(t, active)
+}
+
+func TestRunIgnoresOtherWorkerWithSameName(t *testing.T) {
+	// Another replica, or a registration left over from the last process, is active under the same name
+	c := withListedWorker(t, unreachableClient(t), "unreachable", testWorkerID)
+	s, err := New(c, "unreachable", discard, WithStartTimeout(100*time.Millisecond))
+	require.NoError(t, err)
+
+	err = s.Run(context.Background())
+	assert.ErrorIs(t, err, errStartTimeout)
+	assert.Equal(t, StateFailed, s.Status().State)
+	assert.Error(t, s.Check(context.Background()))
+}
+
+func TestRunRetriesThenGivesUp(t *testing.T) {
+	s, err := New(unreachableClient(t), "unreachable", discard,
+		WithStartTimeout(100*time.Millisecond),
+		WithRetry(1, 10*time.Millisecond, 10*time.Millisecond),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, StateStarting, s.Status().State)
+
+	err = s.Run(context.Background())
+	assert.ErrorContains(t, err, "failed after 2 attempts")
+	assert.ErrorIs(t, err, errStartTimeout)
+
+	status := s.Status()
+	assert.Equal(t, StateFailed, status.State)
+	assert.Equal(t, 1, status.Restarts)
+	assert.Contains(t, status.LastError, "did not become active")
+	assert.Equal(t, status, s.Details(), "readiness reports show the status")
+}
+
+func TestRunStopsOnCancel(t *testing.T) {
+	s, err := New(unreachableClient(t), "unreachable", discard, WithStartTimeout(time.Minute))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	require.NoError(t, s.Run(ctx))
+	assert.Equal(t, StateStopped, s.Status().State)
+}
+
+func TestDrainWaitsForRunningSteps(t *testing.T) {
+	s := &Supervisor{logger: discard}
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go s.track(nil, func(worker.HatchetContext) error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	assert.Equal(t, 1, s.InFlight())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := s.Drain(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "1 step runs still running")
+
+	close(release)
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, s.Drain(ctx))
+	assert.Equal(t, 0, s.InFlight())
+}
@@ -0,0 +1,63 @@
+package testsuite
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/arun0009/hatchetest/pkg/apitoken"
+	"github.com/google/uuid"
+	"github.com/hatchet-dev/hatchet/pkg/client/rest"
+)
+
+// createTenant creates a V1 tenant and returns its ID. The logged-in user becomes its owner.
+func createTenant(ctx context.Context, session *apitoken.Session, name, slug string) (string, error) {
+	version := rest.TenantVersionV1
+	resp, err := session.API().TenantCreateWithResponse(ctx, rest.CreateTenantRequest{
+		Name:          name,
+		Slug:          slug,
+		EngineVersion: &version,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create tenant %s: %w", slug, err)
+	}
+	if resp.JSON200 == nil {
+		return "", fmt.Errorf("failed to create tenant %s: %s: %s", slug, resp.Status(), strings.TrimSpace(string(resp.Body)))
+	}
+	return resp.JSON200.Metadata.Id, nil
+}
+
+// revokeAPITokens revokes every API token belonging to tenantID
+func revokeAPITokens(ctx context.Context, session *apitoken.Session, tenantID string) error {
+	id, err := uuid.Parse(tenantID)
+	if err != nil {
+		return fmt.Errorf("invalid tenant ID %q: %w", tenantID, err)
+	}
+
+	resp, err := session.API().ApiTokenListWithResponse(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to list API tokens: %w", err)
+	}
+	if resp.JSON200 == nil {
+		return fmt.Errorf("failed to list API tokens: %s", resp.Status())
+	}
+	if resp.JSON200.Rows == nil {
+		return nil
+	}
+
+	for _, token := range *resp.JSON200.Rows {
+		tokenID, err := uuid.Parse(token.Metadata.Id)
+		if err != nil {
+			return fmt.Errorf("invalid API token ID %q: %w", token.Metadata.Id, err)
+		}
+		revokeResp, err := session.API().ApiTokenUpdateRevokeWithResponse(ctx, tokenID)
+		if err != nil {
+			return fmt.Errorf("failed to revoke API token %s: %w", token.Name, err)
+		}
+		if revokeResp.StatusCode() != http.StatusNoContent && revokeResp.StatusCode() != http.StatusOK {
+			return fmt.Errorf("failed to revoke API token %s: %s", token.Name, revokeResp.Status())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package testsuite
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// Names accepted by ContainerLogs
+const (
+	PostgresContainerName = "postgres"
+	HatchetContainerName  = "hatchet"
+)
+
+const (
+	// maxRetainedLogLines bounds how much of each container's log is kept in memory
+	maxRetainedLogLines = 5000
+
+	defaultLogTailLines = 200
+)
+
+// WithLogTailLines sets how many trailing log lines per container are attached to a failing test
+func WithLogTailLines(n int) Option {
+	return func(s *SharedTestSuite) {
+		s.logTailLines = n
+	}
+}
+
+// logBuffer keeps the most recent lines a container wrote to stdout and stderr
+type logBuffer struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+// Accept implements testcontainers.LogConsumer
+func (b *logBuffer) Accept(l testcontainers.Log) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lines = append(b.lines, strings.Split(strings.TrimRight(string(l.Content), "\n"), "\n")...)
+	if over := len(b.lines) - maxRetainedLogLines; over > 0 {
+		b.lines = append(b.lines[:0], b.lines[over:]...)
+	}
+}
+
+// tail returns up to the last n lines
+func (b *logBuffer) tail(n int) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if n <= 0 || n > len(b.lines) {
+		n = len(b.lines)
+	}
+	return append([]string(nil), b.lines[len(b.lines)-n:]...)
+}
+
+// logConsumerConfig creates the log buffer for a container and returns the config that feeds it
+func (s *SharedTestSuite) logConsumerConfig(name string) *testcontainers.LogConsumerConfig {
+	s.logsMu.Lock()
+	defer s.logsMu.Unlock()
+
+	if s.logs == nil {
+		s.logs = make(map[string]*logBuffer)
+	}
+	buf := &logBuffer{}
+	s.logs[name] = buf
+	return &testcontainers.LogConsumerConfig{Consumers: []testcontainers.LogConsumer{buf}}
+}
+
+// ContainerLogs returns everything retained from a container's output, e.g. ContainerLogs(HatchetContainerName).
+// It is empty in external mode or for unknown names.
+func (s *SharedTestSuite) ContainerLogs(name string) string {
+	s.logsMu.Lock()
+	buf := s.logs[name]
+	s.logsMu.Unlock()
+
+	if buf == nil {
+		return ""
+	}
+	return strings.Join(buf.tail(0), "\n")
+}
+
+// logTails returns the last lines of every captured container, keyed by container name
+func (s *SharedTestSuite) logTails() map[string][]string {
+	n := s.logTailLines
+	if n == 0 {
+		n = defaultLogTailLines
+	}
+
+	s.logsMu.Lock()
+	defer s.logsMu.Unlock()
+
+	tails := make(map[string][]string, len(s.logs))
+	for name, buf := range s.logs {
+		tails[name] = buf.tail(n)
+	}
+	return tails
+}
+
+// DumpLogsOnFailure attaches the tail of every container log to t's output if t fails.
+// GetOrCreateGlobalShared and SetupSuite register it automatically.
+func (s *SharedTestSuite) DumpLogsOnFailure(t testing.TB) {
+	t.Cleanup(func() {
+		if t.Failed() {
+			writeLogTails(t, s.logTails())
+		}
+	})
+}
+
+func writeLogTails(t testing.TB, tails map[string][]string) {
+	t.Helper()
+	for _, name := range []string{PostgresContainerName, HatchetContainerName} {
+		lines, ok := tails[name]
+		if !ok {
+			continue
+		}
+		t.Logf("---- last %d lines of %s container logs ----\n%s", len(lines), name, strings.Join(lines, "\n"))
+	}
+}
@@ -0,0 +1,22 @@
+package testsuite
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestLogBufferKeepsMostRecentLines(t *testing.T) {
+	buf := &logBuffer{}
+	for i := 0; i < maxRetainedLogLines+10; i++ {
+		buf.Accept(testcontainers.Log{Content: []byte(fmt.Sprintf("line %d\n", i))})
+	}
+
+	assert.Len(t, buf.tail(0), maxRetainedLogLines)
+	assert.Equal(t, []string{
+		fmt.Sprintf("line %d", maxRetainedLogLines+8),
+		fmt.Sprintf("line %d", maxRetainedLogLines+9),
+	}, buf.tail(2))
+}
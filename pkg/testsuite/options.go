@@ -0,0 +1,181 @@
+package testsuite
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/arun0009/hatchetest/pkg/logging"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+const (
+	defaultPostgresImage = "postgres:15-alpine"
+	defaultHatchetImage  = "ghcr.io/hatchet-dev/hatchet/hatchet-lite:latest"
+
+	// Environment variables that override the default images when no option is given
+	postgresImageEnv = "HATCHETEST_POSTGRES_IMAGE"
+	hatchetImageEnv  = "HATCHETEST_HATCHET_IMAGE"
+
+	// Environment variables that select external mode; setting the Hatchet URL turns it on
+	externalHatchetURLEnv     = "HATCHETEST_HATCHET_URL"
+	externalHatchetGRPCURLEnv = "HATCHETEST_HATCHET_GRPC_URL"
+	externalPostgresURLEnv    = "HATCHETEST_POSTGRES_URL"
+	externalHatchetTokenEnv   = "HATCHETEST_HATCHET_TOKEN"
+
+	// Environment variables for the default logger, see defaultLogger
+	logLevelEnv  = "HATCHETEST_LOG_LEVEL"
+	logFormatEnv = "HATCHETEST_LOG_FORMAT"
+)
+
+// ExternalStack describes an already-running Hatchet stack, such as the one from docker-compose.yml
+type ExternalStack struct {
+	HatchetURL     string
+	HatchetGRPCURL string
+	// PostgresURL is optional and only recorded on the suite
+	PostgresURL string
+	// Token is used as-is when set. Otherwise one is minted for the default
+	// tenant by logging in to the REST API as the seeded admin user.
+	Token string
+}
+
+// Option configures a SharedTestSuite created by NewSharedEnvironment
+type Option func(*SharedTestSuite)
+
+// WithPostgresImage sets the Postgres image, e.g. "postgres:15.6-alpine"
+func WithPostgresImage(image string) Option {
+	return func(s *SharedTestSuite) {
+		s.postgresImage = image
+	}
+}
+
+// WithHatchetImage sets the hatchet-lite image. Pin a tag or digest to keep runs reproducible.
+func WithHatchetImage(image string) Option {
+	return func(s *SharedTestSuite) {
+		s.hatchetImage = image
+	}
+}
+
+// WithHatchetEnv adds or overrides environment variables passed to the Hatchet container
+func WithHatchetEnv(env map[string]string) Option {
+	return func(s *SharedTestSuite) {
+		if s.hatchetEnv == nil {
+			s.hatchetEnv = make(map[string]string, len(env))
+		}
+		for k, v := range env {
+			s.hatchetEnv[k] = v
+		}
+	}
+}
+
+// WithLogger sets the logger for setup, teardown, the test server and workers.
+// The default writes text to stderr at $HATCHETEST_LOG_LEVEL (info), or as $HATCHETEST_LOG_FORMAT.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *SharedTestSuite) {
+		s.logger = logger
+	}
+}
+
+// WithExternalStack attaches to an already-running stack instead of starting containers.
+// Teardown leaves the external stack running.
+func WithExternalStack(stack ExternalStack) Option {
+	return func(s *SharedTestSuite) {
+		s.external = &stack
+	}
+}
+
+// applyDefaults fills in anything not set through options.
+// Precedence is option, then HATCHETEST_* environment variable, then built-in default.
+func (s *SharedTestSuite) applyDefaults() {
+	if s.external == nil && os.Getenv(externalHatchetURLEnv) != "" {
+		s.external = &ExternalStack{
+			HatchetURL:     os.Getenv(externalHatchetURLEnv),
+			HatchetGRPCURL: os.Getenv(externalHatchetGRPCURLEnv),
+			PostgresURL:    os.Getenv(externalPostgresURLEnv),
+			Token:          os.Getenv(externalHatchetTokenEnv),
+		}
+	}
+	if s.postgresImage == "" {
+		s.postgresImage = envOrDefault(postgresImageEnv, defaultPostgresImage)
+	}
+	if s.hatchetImage == "" {
+		s.hatchetImage = envOrDefault(hatchetImageEnv, defaultHatchetImage)
+	}
+}
+
+// hatchetContainerEnv returns the default Hatchet environment merged with any WithHatchetEnv overrides
+func (s *SharedTestSuite) hatchetContainerEnv() map[string]string {
+	env := map[string]string{
+		"DATABASE_URL":                                           internalPostgresURL,
+		"SERVER_AUTH_COOKIE_DOMAIN":                              "localhost",
+		"SERVER_AUTH_COOKIE_INSECURE":                            "t",
+		"SERVER_GRPC_BIND_ADDRESS":                               "0.0.0.0",
+		"SERVER_GRPC_INSECURE":                                   "t",
+		"SERVER_GRPC_BROADCAST_ADDRESS":                          "localhost:7077",
+		"SERVER_GRPC_PORT":                                       "7077",
+		"SERVER_URL":                                             "http://localhost:8888",
+		"SERVER_AUTH_SET_EMAIL_VERIFIED":                         "t",
+		"SERVER_DEFAULT_ENGINE_VERSION":                          "V1",
+		"SERVER_INTERNAL_CLIENT_INTERNAL_GRPC_BROADCAST_ADDRESS": "localhost:7077",
+	}
+	if s.hatchetTLS != "" {
+		for k, v := range s.hatchetTLSEnv() {
+			env[k] = v
+		}
+	}
+	for k, v := range s.hatchetEnv {
+		env[k] = v
+	}
+	return env
+}
+
+// defaultLogger is the logger used when WithLogger is not given
+var defaultLogger = sync.OnceValue(func() *slog.Logger {
+	level, err := logging.ParseLevel(envOrDefault(logLevelEnv, "info"))
+	if err != nil {
+		level = slog.LevelInfo
+	}
+	return logging.New(os.Stderr, envOrDefault(logFormatEnv, logging.FormatText), level)
+})
+
+// Logger returns the suite's logger, for tests that want their lines next to the suite's
+func (s *SharedTestSuite) Logger() *slog.Logger {
+	if s.logger == nil {
+		return defaultLogger()
+	}
+	return s.logger
+}
+
+func envOrDefault(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// resolveImageDigest returns the repo digest (name@sha256:...) of the image a container runs.
+// Images without a repo digest, such as locally built ones, fall back to the image ID.
+func resolveImageDigest(ctx context.Context, ctr testcontainers.Container) (string, error) {
+	inspect, err := ctr.Inspect(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	cli, err := testcontainers.NewDockerClientWithOpts(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to create docker client: %w", err)
+	}
+	defer cli.Close()
+
+	image, err := cli.ImageInspect(ctx, inspect.Image)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect image %s: %w", inspect.Image, err)
+	}
+	if len(image.RepoDigests) > 0 {
+		return image.RepoDigests[0], nil
+	}
+	return image.ID, nil
+}
@@ -0,0 +1,81 @@
+package testsuite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/arun0009/hatchetest/pkg/logging"
+	"github.com/arun0009/hatchetest/pkg/runs"
+	"github.com/hatchet-dev/hatchet/pkg/client/rest"
+	"github.com/stretchr/testify/assert"
+)
+
+// defaultAssertTimeout bounds how long the Assert* helpers wait for a run to finish
+const defaultAssertTimeout = 60 * time.Second
+
+// RunWorkflow triggers a workflow through the admin API and returns the run ID.
+// The admin client does not take a context, so ctx is only checked before the call.
+func (s *SharedTestSuite) RunWorkflow(ctx context.Context, name string, input any) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	run, err := s.HatchetClient.Admin().RunWorkflow(name, input)
+	if err != nil {
+		return "", fmt.Errorf("failed to run workflow %s: %w", name, err)
+	}
+	s.Logger().DebugContext(logging.WithRunID(ctx, run.RunId()), "workflow triggered", "workflow", name)
+	return run.RunId(), nil
+}
+
+// WaitForRun waits up to timeout for a run to finish and checks it ended in status.
+// It returns the run details either way, so callers can inspect what went wrong.
+func (s *SharedTestSuite) WaitForRun(ctx context.Context, runID string, status rest.V1TaskStatus, timeout time.Duration) (*rest.V1WorkflowRunDetails, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	details, err := runs.Wait(ctx, s.HatchetClient, runID)
+	if err != nil {
+		return nil, err
+	}
+	s.Logger().DebugContext(logging.WithRunID(ctx, runID), "workflow run finished", "status", details.Run.Status)
+	if details.Run.Status != status {
+		return details, fmt.Errorf("run %s finished as %s, want %s: %s", runID, details.Run.Status, status, runs.ErrorMessage(details))
+	}
+	return details, nil
+}
+
+// AssertStepOutput waits for a run to complete and asserts that step returned expected,
+// compared as JSON. Failures are reported to t.
+func (s *SharedTestSuite) AssertStepOutput(t testing.TB, runID, step string, expected any) bool {
+	t.Helper()
+
+	details, err := s.WaitForRun(context.Background(), runID, rest.V1TaskStatusCOMPLETED, defaultAssertTimeout)
+	if !assert.NoError(t, err, "run %s did not complete", runID) {
+		return false
+	}
+
+	actual, err := runs.StepOutput(details, step)
+	if !assert.NoError(t, err) {
+		return false
+	}
+	want, err := json.Marshal(expected)
+	if !assert.NoError(t, err, "expected output is not JSON serializable") {
+		return false
+	}
+	return assert.JSONEq(t, string(want), string(actual), "output of step %s", step)
+}
+
+// AssertRunFailed waits for a run to fail and asserts its error mentions errSubstring.
+// Failures are reported to t.
+func (s *SharedTestSuite) AssertRunFailed(t testing.TB, runID, errSubstring string) bool {
+	t.Helper()
+
+	details, err := s.WaitForRun(context.Background(), runID, rest.V1TaskStatusFAILED, defaultAssertTimeout)
+	if !assert.NoError(t, err, "run %s did not fail", runID) {
+		return false
+	}
+	return assert.Contains(t, runs.ErrorMessage(details), errSubstring, "error of run %s", runID)
+}
@@ -0,0 +1,23 @@
+package testsuite
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartTestServerUsesDistinctReadyPorts(t *testing.T) {
+	first, second := &SharedTestSuite{}, &SharedTestSuite{}
+	for _, s := range []*SharedTestSuite{first, second} {
+		require.NoError(t, s.startTestServer())
+		t.Cleanup(func() { s.TearDown() })
+	}
+
+	require.NotEqual(t, first.TestServerURL, second.TestServerURL)
+
+	resp, err := http.Get(first.TestServerURL + "/health")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
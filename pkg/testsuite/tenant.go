@@ -0,0 +1,80 @@
+package testsuite
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/arun0009/hatchetest/pkg/apitoken"
+	"github.com/google/uuid"
+	"github.com/hatchet-dev/hatchet/pkg/client"
+	"github.com/hatchet-dev/hatchet/pkg/worker"
+)
+
+// Tenant is a Hatchet tenant owned by a single test. Workflows, events and runs
+// created through its client are invisible to every other tenant.
+type Tenant struct {
+	ID     string
+	Token  string
+	Client client.Client
+
+	logger *slog.Logger
+}
+
+// NewWorker creates a worker bound to the tenant's client
+func (tn *Tenant) NewWorker(opts ...worker.WorkerOpt) (*worker.Worker, error) {
+	return worker.NewWorker(append([]worker.WorkerOpt{worker.WithClient(tn.Client)}, opts...)...)
+}
+
+// NewTenant creates a fresh tenant for t, mints a token scoped to it and returns a client for it.
+// Hatchet has no API to delete tenants, so cleanup revokes the tenant's tokens through t.Cleanup;
+// the tenant and its runs stay in the database but can no longer be reached.
+func (s *SharedTestSuite) NewTenant(t testing.TB) *Tenant {
+	t.Helper()
+	ctx := context.Background()
+
+	session, err := apitoken.Login(ctx, s.HatchetURL, apitoken.DefaultAdminEmail, apitoken.DefaultAdminPassword)
+	if err != nil {
+		t.Fatalf("Failed to open Hatchet admin session: %v", err)
+	}
+
+	suffix := uuid.NewString()[:8]
+	tenantID, err := createTenant(ctx, session, uniqueName(t.Name(), suffix), "hatchetest-"+suffix)
+	if err != nil {
+		t.Fatalf("Failed to create tenant: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := revokeAPITokens(context.Background(), session, tenantID); err != nil {
+			t.Errorf("Failed to clean up tenant %s: %v", tenantID, err)
+		}
+	})
+
+	provisioner := &apitoken.RESTProvisioner{Session: session}
+	token, err := provisioner.Create(ctx, apitoken.CreateRequest{Name: "hatchetest", TenantID: tenantID})
+	if err != nil {
+		t.Fatalf("Failed to create token for tenant %s: %v", tenantID, err)
+	}
+
+	tenantClient, err := s.newHatchetClient(token.Raw, client.WithTenantId(tenantID))
+	if err != nil {
+		t.Fatalf("Failed to create client for tenant %s: %v", tenantID, err)
+	}
+
+	return &Tenant{
+		ID:     tenantID,
+		Token:  token.Raw,
+		Client: tenantClient,
+		logger: s.Logger().With("tenant", tenantID),
+	}
+}
+
+// uniqueName builds a readable resource name from the test name, keeping it short enough for the UI
+func uniqueName(testName, suffix string) string {
+	name := strings.NewReplacer("/", "-", " ", "-").Replace(testName)
+	if len(name) > 40 {
+		name = name[:40]
+	}
+	return fmt.Sprintf("%s-%s", name, suffix)
+}
@@ -11,7 +11,7 @@ import (
 func TestDummy(t *testing.T) {
 	// This test does nothing but ensures TestMain is called
 	// which sets up GlobalShared for other packages to use
-	shared := GetOrCreateGlobalShared()
+	shared := GetOrCreateGlobalShared(t)
 	if shared == nil {
 		t.Fatal("Failed to get or create GlobalShared")
 	}
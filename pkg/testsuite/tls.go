@@ -0,0 +1,190 @@
+package testsuite
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+const (
+	// hatchetCertDir is where the generated certificates are copied in the Hatchet container
+	hatchetCertDir = "/hatchetest-certs"
+
+	// tlsServerName is the name the generated server certificate is issued for
+	tlsServerName = "localhost"
+)
+
+// TLSFiles are the PEM files generated for a TLS-enabled Hatchet container
+type TLSFiles struct {
+	Dir            string
+	CAFile         string
+	ServerCertFile string
+	ServerKeyFile  string
+	// ClientCertFile and ClientKeyFile identify the test client under mtls
+	ClientCertFile string
+	ClientKeyFile  string
+}
+
+// WithHatchetTLS starts hatchet-lite with its gRPC endpoint behind TLS, using a throwaway CA and
+// certificates generated for the run. strategy is "tls" or "mtls"; with mtls the suite's clients
+// present a client certificate signed by the same CA. It has no effect in external mode.
+func WithHatchetTLS(strategy string) Option {
+	return func(s *SharedTestSuite) {
+		s.hatchetTLS = strategy
+	}
+}
+
+// generateTLSCerts writes a CA, a server certificate for localhost and a client certificate to a temp dir
+func (s *SharedTestSuite) generateTLSCerts(ctx context.Context) error {
+	if s.hatchetTLS != "tls" && s.hatchetTLS != "mtls" {
+		return fmt.Errorf("unsupported Hatchet TLS strategy %q, want tls or mtls", s.hatchetTLS)
+	}
+
+	dir, err := os.MkdirTemp("", "hatchetest-certs-")
+	if err != nil {
+		return fmt.Errorf("failed to create certificate dir: %w", err)
+	}
+	files := &TLSFiles{
+		Dir:            dir,
+		CAFile:         filepath.Join(dir, "ca.pem"),
+		ServerCertFile: filepath.Join(dir, "server.pem"),
+		ServerKeyFile:  filepath.Join(dir, "server-key.pem"),
+		ClientCertFile: filepath.Join(dir, "client.pem"),
+		ClientKeyFile:  filepath.Join(dir, "client-key.pem"),
+	}
+	s.HatchetTLSFiles = files
+
+	caCert, caKey, err := issueCert(nil, nil, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "hatchetest CA"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+	}, files.CAFile, "")
+	if err != nil {
+		return fmt.Errorf("failed to generate CA: %w", err)
+	}
+
+	if _, _, err := issueCert(caCert, caKey, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: tlsServerName},
+		DNSNames:    []string{tlsServerName, HatchetContainerName},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1"), net.IPv6loopback},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, files.ServerCertFile, files.ServerKeyFile); err != nil {
+		return fmt.Errorf("failed to generate server certificate: %w", err)
+	}
+
+	if _, _, err := issueCert(caCert, caKey, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "hatchetest client"},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, files.ClientCertFile, files.ClientKeyFile); err != nil {
+		return fmt.Errorf("failed to generate client certificate: %w", err)
+	}
+
+	s.Logger().Info("generated TLS certificates", "strategy", s.hatchetTLS, "dir", dir)
+	return nil
+}
+
+// issueCert creates a certificate from template signed by parent, or self-signed when parent is nil,
+// and writes it to certFile and its key to keyFile, if given
+func issueCert(parent *x509.Certificate, parentKey *ecdsa.PrivateKey, template *x509.Certificate, certFile, keyFile string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(24 * time.Hour)
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := writePEM(certFile, "CERTIFICATE", der); err != nil {
+		return nil, nil, err
+	}
+	if keyFile != "" {
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := writePEM(keyFile, "EC PRIVATE KEY", keyDER); err != nil {
+			return nil, nil, err
+		}
+	}
+	return cert, key, nil
+}
+
+func writePEM(path, blockType string, der []byte) error {
+	// The Hatchet container runs as a different user, so the files must be world readable there
+	return os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o644)
+}
+
+// hatchetTLSEnv returns the Hatchet container settings that serve gRPC with the generated certificates,
+// and point the engine's internal client at them as well
+func (s *SharedTestSuite) hatchetTLSEnv() map[string]string {
+	env := map[string]string{
+		"SERVER_GRPC_INSECURE":                         "f",
+		"SERVER_TLS_STRATEGY":                          s.hatchetTLS,
+		"SERVER_TLS_CERT_FILE":                         hatchetCertDir + "/server.pem",
+		"SERVER_TLS_KEY_FILE":                          hatchetCertDir + "/server-key.pem",
+		"SERVER_TLS_ROOT_CA_FILE":                      hatchetCertDir + "/ca.pem",
+		"SERVER_TLS_SERVER_NAME":                       tlsServerName,
+		"SERVER_INTERNAL_CLIENT_BASE_STRATEGY":         s.hatchetTLS,
+		"SERVER_INTERNAL_CLIENT_TLS_SERVER_NAME":       tlsServerName,
+		"SERVER_INTERNAL_CLIENT_TLS_BASE_ROOT_CA_FILE": hatchetCertDir + "/ca.pem",
+	}
+	if s.hatchetTLS == "mtls" {
+		env["SERVER_INTERNAL_CLIENT_TLS_BASE_CERT_FILE"] = hatchetCertDir + "/client.pem"
+		env["SERVER_INTERNAL_CLIENT_TLS_BASE_KEY_FILE"] = hatchetCertDir + "/client-key.pem"
+	}
+	return env
+}
+
+// hatchetTLSContainerFiles copies the generated certificates into the Hatchet container
+func (s *SharedTestSuite) hatchetTLSContainerFiles() []testcontainers.ContainerFile {
+	files := s.HatchetTLSFiles
+	if files == nil {
+		return nil
+	}
+	var out []testcontainers.ContainerFile
+	for host, name := range map[string]string{
+		files.CAFile:         "ca.pem",
+		files.ServerCertFile: "server.pem",
+		files.ServerKeyFile:  "server-key.pem",
+		files.ClientCertFile: "client.pem",
+		files.ClientKeyFile:  "client-key.pem",
+	} {
+		out = append(out, testcontainers.ContainerFile{
+			HostFilePath:      host,
+			ContainerFilePath: hatchetCertDir + "/" + name,
+			FileMode:          0o644,
+		})
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package testsuite
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"testing"
+
+	"github.com/hatchet-dev/hatchet/pkg/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGenerateTLSCerts verifies the generated server and client certificates chain to the generated CA
+func TestGenerateTLSCerts(t *testing.T) {
+	s := &SharedTestSuite{hatchetTLS: "mtls"}
+	require.NoError(t, s.generateTLSCerts(context.Background()))
+	files := s.HatchetTLSFiles
+	t.Cleanup(func() { os.RemoveAll(files.Dir) })
+
+	caPEM, err := os.ReadFile(files.CAFile)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(caPEM))
+
+	server, err := tls.LoadX509KeyPair(files.ServerCertFile, files.ServerKeyFile)
+	require.NoError(t, err)
+	serverCert, err := x509.ParseCertificate(server.Certificate[0])
+	require.NoError(t, err)
+	_, err = serverCert.Verify(x509.VerifyOptions{Roots: roots, DNSName: tlsServerName})
+	assert.NoError(t, err)
+
+	client, err := tls.LoadX509KeyPair(files.ClientCertFile, files.ClientKeyFile)
+	require.NoError(t, err)
+	clientCert, err := x509.ParseCertificate(client.Certificate[0])
+	require.NoError(t, err)
+	_, err = clientCert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	assert.NoError(t, err)
+
+	cfg := s.appConfig("token")
+	assert.Equal(t, "mtls", cfg.HatchetTLSStrategy)
+	assert.Equal(t, files.ClientCertFile, cfg.HatchetTLSCertFile)
+	assert.Len(t, s.hatchetTLSContainerFiles(), 5)
+	assert.Equal(t, "f", s.hatchetContainerEnv()["SERVER_GRPC_INSECURE"])
+}
+
+// TestHatchetTLS runs a workflow against a separate hatchet-lite whose gRPC endpoint requires TLS
+func TestHatchetTLS(t *testing.T) {
+	if os.Getenv(externalHatchetURLEnv) != "" {
+		t.Skip("No containers are started in external mode")
+	}
+
+	env, err := NewSharedEnvironment(context.Background(), WithHatchetTLS("tls"))
+	if err != nil {
+		logSetupFailure(t, err)
+		t.Fatalf("Failed to start TLS Hatchet: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := env.TearDown(); err != nil {
+			t.Errorf("Failed to tear down TLS Hatchet: %v", err)
+		}
+	})
+	env.DumpLogsOnFailure(t)
+
+	workflow := &worker.WorkflowJob{
+		Name: "hatchetest-tls",
+		On:   worker.NoTrigger(),
+		Steps: []*worker.WorkflowStep{
+			worker.Fn(func(ctx worker.HatchetContext) (*greetOutput, error) {
+				return &greetOutput{Greeting: "hello over tls"}, nil
+			}).SetName("greet"),
+		},
+	}
+	env.StartWorker(t, nil, workflow)
+
+	runID, err := env.RunWorkflow(context.Background(), workflow.Name, map[string]any{})
+	require.NoError(t, err)
+	env.AssertStepOutput(t, runID, "greet", greetOutput{Greeting: "hello over tls"})
+}
@@ -0,0 +1,127 @@
+package testsuite
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/arun0009/hatchetest/pkg/logging"
+	"github.com/arun0009/hatchetest/pkg/supervisor"
+	"github.com/google/uuid"
+	"github.com/hatchet-dev/hatchet/pkg/client"
+	"github.com/hatchet-dev/hatchet/pkg/worker"
+)
+
+const (
+	// workerReadyTimeout bounds how long StartWorker waits for Hatchet to list the worker as active
+	workerReadyTimeout = 30 * time.Second
+	// workerStopTimeout bounds how long cleanup waits for the worker to unregister
+	workerStopTimeout = 10 * time.Second
+
+	workerPollInterval = 100 * time.Millisecond
+)
+
+// StartWorker registers workflows on a new worker, starts it and waits until Hatchet reports it active.
+// The worker is named after the test with a random suffix, and is stopped through t.Cleanup.
+// opts are applied before the name and the suite's client; use Tenant.StartWorker for a tenant's client.
+func (s *SharedTestSuite) StartWorker(t testing.TB, opts []worker.WorkerOpt, workflows ...*worker.WorkflowJob) *worker.Worker {
+	t.Helper()
+	return startWorker(t, s.HatchetClient, s.Logger(), opts, workflows)
+}
+
+// StartWorker is SharedTestSuite.StartWorker for a worker bound to the tenant's client
+func (tn *Tenant) StartWorker(t testing.TB, opts []worker.WorkerOpt, workflows ...*worker.WorkflowJob) *worker.Worker {
+	t.Helper()
+	return startWorker(t, tn.Client, tn.logger, opts, workflows)
+}
+
+func startWorker(t testing.TB, c client.Client, logger *slog.Logger, opts []worker.WorkerOpt, workflows []*worker.WorkflowJob) *worker.Worker {
+	t.Helper()
+
+	name := uniqueName(t.Name(), uuid.NewString()[:8])
+	logger = logger.With("worker", name)
+	opts = append(append([]worker.WorkerOpt{}, opts...), worker.WithClient(c), worker.WithName(name))
+	w, err := worker.NewWorker(opts...)
+	if err != nil {
+		t.Fatalf("Failed to create worker %s: %v", name, err)
+	}
+	for _, wf := range workflows {
+		if err := w.RegisterWorkflow(wf); err != nil {
+			t.Fatalf("Failed to register workflow %s on worker %s: %v", wf.Name, name, err)
+		}
+	}
+	w.Use(logging.Worker(logger))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var runErr error
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		runErr = w.Run(ctx)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		select {
+		case <-stopped:
+			logger.Debug("worker stopped")
+			if runErr != nil {
+				t.Errorf("Worker %s stopped with error: %v", name, runErr)
+			}
+		case <-time.After(workerStopTimeout):
+			t.Errorf("Worker %s did not stop within %s", name, workerStopTimeout)
+		}
+	})
+
+	if err := waitForWorkerActive(ctx, c, name, stopped); err != nil {
+		if isClosed(stopped) && runErr != nil {
+			err = fmt.Errorf("%w: %v", err, runErr)
+		}
+		t.Fatalf("Worker %s did not become active: %v", name, err)
+	}
+	logger.Debug("worker active", "workflows", len(workflows))
+	return w
+}
+
+// waitForWorkerActive polls the tenant's workers until one named name is active.
+// It gives up early if the worker stops, which is signalled by closing stopped.
+func waitForWorkerActive(ctx context.Context, c client.Client, name string, stopped <-chan struct{}) error {
+	ctx, cancel := context.WithTimeout(ctx, workerReadyTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(workerPollInterval)
+	defer ticker.Stop()
+
+	var lastErr error
+	for {
+		active, err := supervisor.WorkerActive(ctx, c, name)
+		if err != nil {
+			lastErr = err
+		}
+		if active {
+			return nil
+		}
+
+		select {
+		case <-stopped:
+			return fmt.Errorf("worker exited")
+		case <-ctx.Done():
+			if lastErr != nil {
+				return fmt.Errorf("%w (last error: %v)", ctx.Err(), lastErr)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}